@@ -0,0 +1,453 @@
+package matcher
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Node is a node of the syntax tree of a pattern, as returned by Pattern.AST:
+// an *ObjectNode, an *ArrayNode, a *LiteralNode, a *MarkerNode or a
+// *CombinatorNode.
+// Markers always use the canonical `#` prefix, regardless of
+// WithMarkerPrefix.
+type Node interface {
+	// spec converts the node back into the pattern specifier it represents.
+	spec() (interface{}, error)
+}
+
+// ObjectNode is an object pattern.
+type ObjectNode struct {
+	// Members are the keys of the object pattern, sorted by key.
+	Members []*Member
+	// Where holds the `#where` expressions of the object pattern.
+	Where []string
+	// Properties is the `#properties` range of the object pattern, or empty.
+	Properties string
+}
+
+// Member is a key of an object pattern, with the pattern of its value.
+type Member struct {
+	// Key is the key matched literally, empty when KeyPattern is set.
+	Key string
+	// KeyPattern is the pattern of a `#key PATTERN` key, matching all the
+	// keys of the object it matches, or nil.
+	KeyPattern Node
+	// Value is the pattern of the value of the key.
+	Value Node
+}
+
+// ArrayNode is an array pattern, matching arrays of the same length item by
+// item.
+type ArrayNode struct {
+	Items []Node
+}
+
+// LiteralNode is a string, number, boolean or null matched literally.
+// Strings starting with `#` are literals too: the escaping with `##` is
+// removed from Value.
+type LiteralNode struct {
+	Value interface{}
+}
+
+// MarkerNode is a marker, e.g. `#regex ^[a-z]+$`, which has Name `#regex`
+// and Args `^[a-z]+$`. References to named patterns (`#use NAME`) and
+// parameters (`#param NAME`) are markers as well.
+type MarkerNode struct {
+	Name string
+	// Args is the text following the name, empty for markers without
+	// arguments.
+	Args string
+}
+
+// CombinatorNode is a pattern built from other patterns: `#array-of`,
+// `#object-of`, `#json`, `#jwt-claims`, `#enum` and `#enum-i`. The values of
+// the enums (including those written in the compact form `#enum a|b|c`)
+// are *LiteralNodes.
+type CombinatorNode struct {
+	Name string
+	Args []Node
+	// compact records that an enum was written in the compact form.
+	compact bool
+}
+
+// AST returns the syntax tree of the pattern. The `#extends` keys of the
+// pattern are already resolved, and the named patterns it can refer to with
+// `#use` are returned by Defs.
+func (p *Pattern) AST() Node {
+	return newNode(p.spec)
+}
+
+// Defs returns the syntax trees of the named patterns the pattern can refer
+// to with `#use`, indexed by name.
+func (p *Pattern) Defs() map[string]Node {
+	defs := make(map[string]Node, len(p.defs))
+	for name, def := range p.defs {
+		defs[name] = newNode(def)
+	}
+	return defs
+}
+
+// CompileAST returns the Pattern represented by the syntax tree `root`,
+// accepting the same options as Compile (markers in `root` use the `#`
+// prefix in any case).
+// Malformed patterns are reported with a *PatternError.
+func CompileAST(root Node, opts ...Option) (*Pattern, error) {
+	o, err := newOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	spec, err := nodeSpec(root)
+	if err != nil {
+		return nil, withSentinel(ErrInvalidPattern, err)
+	}
+	return compileSpec(spec, o)
+}
+
+// Rewrite returns a new pattern whose syntax tree, and those of its named
+// patterns, are transformed with Rewrite and `rewrite`.
+// Malformed results are reported with a *PatternError.
+func (p *Pattern) Rewrite(rewrite func(n Node) (Node, error)) (*Pattern, error) {
+	spec, err := rewriteSpec(p.spec, rewrite)
+	if err != nil {
+		return nil, err
+	}
+	if err := (validator{overlay: p.opts.overlay}).validate(spec, ""); err != nil {
+		return nil, err
+	}
+	defs := make(map[string]interface{}, len(p.defs))
+	for _, name := range sortedMapKeys(p.defs) {
+		def, err := rewriteSpec(p.defs[name], rewrite)
+		if err != nil {
+			return nil, fmt.Errorf("in definition %q: %w", name, err)
+		}
+		if err := (validator{overlay: p.opts.overlay}).validate(def, "/"+defsKey+"/"+escapePointerToken(name)); err != nil {
+			return nil, err
+		}
+		defs[name] = def
+	}
+	if err := checkDefs(spec, defs); err != nil {
+		return nil, withSentinel(ErrInvalidPattern, err)
+	}
+	return makePattern(spec, defs, p.opts), nil
+}
+
+func rewriteSpec(spec interface{}, rewrite func(n Node) (Node, error)) (interface{}, error) {
+	n, err := Rewrite(newNode(spec), rewrite)
+	if err != nil {
+		return nil, err
+	}
+	spec, err = nodeSpec(n)
+	if err != nil {
+		return nil, withSentinel(ErrInvalidPattern, err)
+	}
+	return spec, nil
+}
+
+// Walk traverses the syntax tree `n` depth-first, calling `visit` with each
+// node and the JSON Pointer of its value within the pattern. The children of
+// a node are visited only if `visit` returns true. The key pattern and the
+// value of a member of an object share the pointer of the member, as do the
+// values of a compact enum and the enum itself.
+func Walk(n Node, visit func(pointer string, n Node) bool) {
+	walk("", n, visit)
+}
+
+func walk(pointer string, n Node, visit func(pointer string, n Node) bool) {
+	if n == nil || !visit(pointer, n) {
+		return
+	}
+	switch v := n.(type) {
+	case *ObjectNode:
+		for _, m := range v.Members {
+			key, _ := m.specKey()
+			memberPointer := pointer + "/" + escapePointerToken(key)
+			walk(memberPointer, m.KeyPattern, visit)
+			walk(memberPointer, m.Value, visit)
+		}
+	case *ArrayNode:
+		for i, item := range v.Items {
+			walk(pointer+"/"+strconv.Itoa(i), item, visit)
+		}
+	case *CombinatorNode:
+		for i, arg := range v.Args {
+			argPointer := pointer
+			if !v.compact {
+				argPointer += "/" + strconv.Itoa(i+1)
+			}
+			walk(argPointer, arg, visit)
+		}
+	}
+}
+
+// Rewrite returns a copy of the syntax tree `n` transformed bottom-up: the
+// children of each node are rewritten first, then `rewrite` is called with a
+// copy of the node holding the rewritten children, and its result replaces
+// the node. Returning the node unchanged keeps it; returning nil removes it
+// from its parent object, array or combinator. The tree `n` isn't modified,
+// unless `rewrite` modifies the nodes it is called with.
+func Rewrite(n Node, rewrite func(n Node) (Node, error)) (Node, error) {
+	switch v := n.(type) {
+	case nil:
+		return nil, nil
+	case *ObjectNode:
+		o := &ObjectNode{Where: append([]string(nil), v.Where...), Properties: v.Properties}
+		for _, m := range v.Members {
+			keyPattern, err := Rewrite(m.KeyPattern, rewrite)
+			if err != nil {
+				return nil, err
+			}
+			value, err := Rewrite(m.Value, rewrite)
+			if err != nil {
+				return nil, err
+			}
+			if value == nil || (m.KeyPattern != nil && keyPattern == nil) {
+				continue
+			}
+			o.Members = append(o.Members, &Member{Key: m.Key, KeyPattern: keyPattern, Value: value})
+		}
+		n = o
+	case *ArrayNode:
+		items, err := rewriteNodes(v.Items, rewrite)
+		if err != nil {
+			return nil, err
+		}
+		n = &ArrayNode{Items: items}
+	case *CombinatorNode:
+		args, err := rewriteNodes(v.Args, rewrite)
+		if err != nil {
+			return nil, err
+		}
+		n = &CombinatorNode{Name: v.Name, Args: args, compact: v.compact}
+	case *LiteralNode:
+		n = &LiteralNode{Value: v.Value}
+	case *MarkerNode:
+		n = &MarkerNode{Name: v.Name, Args: v.Args}
+	}
+	return rewrite(n)
+}
+
+func rewriteNodes(nodes []Node, rewrite func(n Node) (Node, error)) ([]Node, error) {
+	rewritten := make([]Node, 0, len(nodes))
+	for _, n := range nodes {
+		r, err := Rewrite(n, rewrite)
+		if err != nil {
+			return nil, err
+		}
+		if r != nil {
+			rewritten = append(rewritten, r)
+		}
+	}
+	return rewritten, nil
+}
+
+// newNode returns the syntax tree of the pattern specifier `spec`, whose
+// markers have the canonical prefix.
+func newNode(spec interface{}) Node {
+	switch v := spec.(type) {
+	case string:
+		isMarker, marker := getMarker(v)
+		if !isMarker {
+			return &LiteralNode{Value: unescapeLiteral(v)}
+		}
+		if isEnum, name, args := getCompactEnum(marker); isEnum {
+			return &CombinatorNode{Name: name, Args: literalNodes(args), compact: true}
+		}
+		//nolint:gomnd // the "magic" literal constant 2 here is clearer than a synthetic constant symbol
+		markerParts := strings.SplitN(marker, " ", 2)
+		n := &MarkerNode{Name: markerParts[0]}
+		//nolint:gomnd // the "magic" literal constant 2 here is clearer than a synthetic constant symbol
+		if len(markerParts) == 2 {
+			n.Args = markerParts[1]
+		}
+		return n
+	case []interface{}:
+		if isCombinator, name, args := getCombinator(v); isCombinator {
+			if name == enumMarker || name == enumMarkerI {
+				return &CombinatorNode{Name: name, Args: literalNodes(args)}
+			}
+			return &CombinatorNode{Name: name, Args: newNodes(args)}
+		}
+		//nolint:gomnd // the "magic" literal constant 2 here is clearer than a synthetic constant symbol
+		if len(v) == 2 && isMarker(v[0], arrayOfMarker) {
+			return &CombinatorNode{Name: arrayOfMarker, Args: newNodes(v[1:])}
+		}
+		return &ArrayNode{Items: newNodes(v)}
+	case map[string]interface{}:
+		o := &ObjectNode{}
+		for _, key := range sortedMapKeys(v) {
+			switch value := v[key]; {
+			case key == whereKey:
+				o.Where = whereSources(value)
+			case key == propertiesKey:
+				o.Properties, _ = value.(string)
+			default:
+				if isKeyPattern, keyPattern := getKeyPattern(key); isKeyPattern {
+					o.Members = append(o.Members, &Member{KeyPattern: newNode(keyPattern), Value: newNode(value)})
+					continue
+				}
+				o.Members = append(o.Members, &Member{Key: unescapeKey(key), Value: newNode(value)})
+			}
+		}
+		return o
+	}
+	return &LiteralNode{Value: spec}
+}
+
+func newNodes(specs []interface{}) []Node {
+	nodes := make([]Node, 0, len(specs))
+	for _, spec := range specs {
+		nodes = append(nodes, newNode(spec))
+	}
+	return nodes
+}
+
+// literalNodes returns the values of an enum as literals: they are never
+// markers.
+func literalNodes(values []interface{}) []Node {
+	nodes := make([]Node, 0, len(values))
+	for _, value := range values {
+		nodes = append(nodes, &LiteralNode{Value: value})
+	}
+	return nodes
+}
+
+// whereSources returns the expressions of a (valid) `#where` value.
+func whereSources(spec interface{}) []string {
+	if source, ok := spec.(string); ok {
+		return []string{source}
+	}
+	items, _ := spec.([]interface{})
+	sources := make([]string, 0, len(items))
+	for _, item := range items {
+		if source, ok := item.(string); ok {
+			sources = append(sources, source)
+		}
+	}
+	return sources
+}
+
+// nodeSpec converts the syntax tree `n` into a pattern specifier.
+func nodeSpec(n Node) (interface{}, error) {
+	if n == nil {
+		return nil, errors.New("missing pattern node")
+	}
+	return n.spec()
+}
+
+func (n *ObjectNode) spec() (interface{}, error) {
+	m := make(map[string]interface{}, len(n.Members))
+	for _, member := range n.Members {
+		key, err := member.specKey()
+		if err != nil {
+			return nil, err
+		}
+		value, err := nodeSpec(member.Value)
+		if err != nil {
+			return nil, fmt.Errorf("in key %q: %w", key, err)
+		}
+		m[key] = value
+	}
+	switch len(n.Where) {
+	case 0:
+	case 1:
+		m[whereKey] = n.Where[0]
+	default:
+		sources := make([]interface{}, 0, len(n.Where))
+		for _, source := range n.Where {
+			sources = append(sources, source)
+		}
+		m[whereKey] = sources
+	}
+	if n.Properties != "" {
+		m[propertiesKey] = n.Properties
+	}
+	return m, nil
+}
+
+// specKey returns the object pattern key of the member.
+func (m *Member) specKey() (string, error) {
+	if m.KeyPattern == nil {
+		if strings.HasPrefix(m.Key, markerPrefix) {
+			return markerPrefix + m.Key, nil
+		}
+		return m.Key, nil
+	}
+	keyPattern, err := m.KeyPattern.spec()
+	if err != nil {
+		return "", err
+	}
+	s, ok := keyPattern.(string)
+	if !ok {
+		return "", fmt.Errorf("%s patterns must be strings, got %s", keyMarker, formatValue(keyPattern))
+	}
+	return keyMarker + " " + s, nil
+}
+
+func (n *ArrayNode) spec() (interface{}, error) {
+	items := make([]interface{}, 0, len(n.Items))
+	for _, item := range n.Items {
+		spec, err := nodeSpec(item)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, spec)
+	}
+	return items, nil
+}
+
+func (n *LiteralNode) spec() (interface{}, error) {
+	if s, ok := n.Value.(string); ok && strings.HasPrefix(s, markerPrefix) {
+		return markerPrefix + s, nil
+	}
+	return n.Value, nil
+}
+
+func (n *MarkerNode) spec() (interface{}, error) {
+	if !strings.HasPrefix(n.Name, markerPrefix) || strings.HasPrefix(n.Name, escapedMarkerPrefix) {
+		return nil, fmt.Errorf("invalid marker name %q", n.Name)
+	}
+	if n.Args == "" {
+		return n.Name, nil
+	}
+	return n.Name + " " + n.Args, nil
+}
+
+func (n *CombinatorNode) spec() (interface{}, error) {
+	if n.Name == enumMarker || n.Name == enumMarkerI {
+		return n.enumSpec()
+	}
+	spec := make([]interface{}, 0, len(n.Args)+1)
+	spec = append(spec, n.Name)
+	for _, arg := range n.Args {
+		argSpec, err := nodeSpec(arg)
+		if err != nil {
+			return nil, err
+		}
+		spec = append(spec, argSpec)
+	}
+	return spec, nil
+}
+
+// enumSpec returns the specifier of an enum, in the compact form if it was
+// written so and its values still allow it.
+func (n *CombinatorNode) enumSpec() (interface{}, error) {
+	values := make([]interface{}, 0, len(n.Args))
+	sources := make([]string, 0, len(n.Args))
+	compact := n.compact && len(n.Args) > 0
+	for _, arg := range n.Args {
+		literal, ok := arg.(*LiteralNode)
+		if !ok {
+			return nil, fmt.Errorf("%s values must be literals, got %T", n.Name, arg)
+		}
+		s, isString := literal.Value.(string)
+		compact = compact && isString && s != "" && !strings.Contains(s, "|")
+		values = append(values, literal.Value)
+		sources = append(sources, s)
+	}
+	if compact {
+		return n.Name + " " + strings.Join(sources, "|"), nil
+	}
+	return append([]interface{}{n.Name}, values...), nil
+}
@@ -0,0 +1,241 @@
+package matcher_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	matcher "github.com/panta/go-json-matcher"
+)
+
+func describeNode(n matcher.Node) string {
+	switch v := n.(type) {
+	case *matcher.ObjectNode:
+		return fmt.Sprintf("object members=%d where=%q properties=%q", len(v.Members), v.Where, v.Properties)
+	case *matcher.ArrayNode:
+		return fmt.Sprintf("array items=%d", len(v.Items))
+	case *matcher.LiteralNode:
+		return fmt.Sprintf("literal %#v", v.Value)
+	case *matcher.MarkerNode:
+		return fmt.Sprintf("marker %s %q", v.Name, v.Args)
+	case *matcher.CombinatorNode:
+		return fmt.Sprintf("combinator %s args=%d", v.Name, len(v.Args))
+	}
+	return fmt.Sprintf("unknown %T", n)
+}
+
+func TestWalk(t *testing.T) {
+	tests := []struct {
+		name  string
+		jSpec string
+		skip  string
+		want  []string
+	}{
+		{name: "object", jSpec: `{ "id": "#uuid", "#key #regex ^x-": "#string", "#where": "id != ''", ` +
+			`"#properties": "1..5", "lit": "##tag" }`,
+			want: []string{
+				`: object members=3 where=["id != ''"] properties="1..5"`,
+				`/#key #regex ^x-: marker #regex "^x-"`,
+				`/#key #regex ^x-: marker #string ""`,
+				`/id: marker #uuid ""`,
+				`/lit: literal "#tag"`,
+			}},
+		{name: "arrays", jSpec: `[ 1, [ "#array-of", "#integer" ], null, true ]`,
+			want: []string{
+				`: array items=4`,
+				`/0: literal 1`,
+				`/1: combinator #array-of args=1`,
+				`/1/1: marker #integer ""`,
+				`/2: literal <nil>`,
+				`/3: literal true`,
+			}},
+		{name: "combinators", jSpec: `[ [ "#object-of", "#regex ^[a-z]+$", [ "#enum", "#a", 2 ] ], "#enum-i x|y" ]`,
+			want: []string{
+				`: array items=2`,
+				`/0: combinator #object-of args=2`,
+				`/0/1: marker #regex "^[a-z]+$"`,
+				`/0/2: combinator #enum args=2`,
+				`/0/2/1: literal "#a"`,
+				`/0/2/2: literal 2`,
+				`/1: combinator #enum-i args=2`,
+				`/1: literal "x"`,
+				`/1: literal "y"`,
+			}},
+		{name: "skip-children", jSpec: `{ "a": { "b": "#string" }, "c": [ "#number" ] }`, skip: "/a",
+			want: []string{
+				`: object members=2 where=[] properties=""`,
+				`/a: object members=1 where=[] properties=""`,
+				`/c: array items=1`,
+				`/c/0: marker #number ""`,
+			}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := matcher.MustCompile(tt.jSpec)
+			got := []string{}
+			matcher.Walk(p.AST(), func(pointer string, n matcher.Node) bool {
+				got = append(got, pointer+": "+describeNode(n))
+				return tt.skip == "" || pointer != tt.skip
+			})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Walk() got =\n%s\nwant =\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	tests := []struct {
+		name    string
+		jSpec   string
+		rewrite func(n matcher.Node) (matcher.Node, error)
+		j       string
+		want    bool
+		wantErr error
+	}{
+		{name: "identity", jSpec: `{ "id": "#uuid", "tags": [ "#array-of", "#enum a|b" ], "lit": "##x", ` +
+			`"#where": [ "len(tags) > 0", "id != ''" ], "#key #regex ^x-": "#string" }`,
+			rewrite: func(n matcher.Node) (matcher.Node, error) { return n, nil },
+			j:       `{ "id": "0d4b2e9c-4a87-4e4c-9a3b-2f1d7a4b5c6e", "tags": [ "a" ], "lit": "#x", "x-y": "z" }`,
+			want:    true},
+		{name: "replace-marker", jSpec: `{ "id": "#uuid", "nested": { "id": "#uuid" } }`,
+			rewrite: func(n matcher.Node) (matcher.Node, error) {
+				if m, ok := n.(*matcher.MarkerNode); ok && m.Name == "#uuid" {
+					return &matcher.MarkerNode{Name: "#string"}, nil
+				}
+				return n, nil
+			},
+			j: `{ "id": "1", "nested": { "id": "2" } }`, want: true},
+		{name: "remove-members", jSpec: `{ "id": "#uuid", "secret": "#string", "list": [ 1, "#ignore", 3 ] }`,
+			rewrite: func(n matcher.Node) (matcher.Node, error) {
+				if m, ok := n.(*matcher.MarkerNode); ok && m.Name != "#uuid" {
+					return nil, nil
+				}
+				return n, nil
+			},
+			j: `{ "id": "0d4b2e9c-4a87-4e4c-9a3b-2f1d7a4b5c6e", "list": [ 1, 3 ] }`, want: true},
+		{name: "definitions", jSpec: `{ "#defs": { "id": "#uuid" }, "user": "#use id" }`,
+			rewrite: func(n matcher.Node) (matcher.Node, error) {
+				if m, ok := n.(*matcher.MarkerNode); ok && m.Name == "#uuid" {
+					return &matcher.MarkerNode{Name: "#integer"}, nil
+				}
+				return n, nil
+			},
+			j: `{ "user": 42 }`, want: true},
+		{name: "enum-values", jSpec: `{ "status": "#enum new|paid" }`,
+			rewrite: func(n matcher.Node) (matcher.Node, error) {
+				if c, ok := n.(*matcher.CombinatorNode); ok && c.Name == "#enum" {
+					c.Args = append(c.Args, &matcher.LiteralNode{Value: "shipped"})
+				}
+				return n, nil
+			},
+			j: `{ "status": "shipped" }`, want: true},
+		{name: "malformed-result", jSpec: `{ "a": { "b": "#string" } }`,
+			rewrite: func(n matcher.Node) (matcher.Node, error) {
+				if m, ok := n.(*matcher.MarkerNode); ok {
+					return &matcher.MarkerNode{Name: m.Name + "x"}, nil
+				}
+				return n, nil
+			},
+			wantErr: matcher.ErrUnknownMarker},
+		{name: "undefined-use", jSpec: `{ "#defs": { "id": "#uuid" }, "user": "#use id" }`,
+			rewrite: func(n matcher.Node) (matcher.Node, error) {
+				if m, ok := n.(*matcher.MarkerNode); ok && m.Name == "#use" {
+					return &matcher.MarkerNode{Name: "#use", Args: "missing"}, nil
+				}
+				return n, nil
+			},
+			wantErr: matcher.ErrInvalidPattern},
+		{name: "callback-error", jSpec: `{ "a": "#string" }`,
+			rewrite: func(n matcher.Node) (matcher.Node, error) { return nil, errBoom },
+			wantErr: errBoom},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := matcher.MustCompile(tt.jSpec)
+			before := describeTree(p.AST())
+			rewritten, err := p.Rewrite(tt.rewrite)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Rewrite() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Rewrite() error = %v", err)
+			}
+			if after := describeTree(p.AST()); after != before {
+				t.Errorf("Rewrite() modified the pattern:\n%s\nwas:\n%s", after, before)
+			}
+			got, err := rewritten.Matches([]byte(tt.j))
+			if err != nil {
+				t.Fatalf("Matches() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Matches() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+var errBoom = errors.New("boom")
+
+func describeTree(n matcher.Node) string {
+	var lines []string
+	matcher.Walk(n, func(pointer string, n matcher.Node) bool {
+		lines = append(lines, pointer+": "+describeNode(n))
+		return true
+	})
+	return strings.Join(lines, "\n")
+}
+
+func TestCompileAST(t *testing.T) {
+	root := &matcher.ObjectNode{
+		Members: []*matcher.Member{
+			{Key: "id", Value: &matcher.MarkerNode{Name: "#regex", Args: "^[0-9]+$"}},
+			{Key: "tag", Value: &matcher.LiteralNode{Value: "#literal"}},
+			{KeyPattern: &matcher.MarkerNode{Name: "#starts-with", Args: "x-"}, Value: &matcher.MarkerNode{Name: "#integer"}},
+			{Key: "items", Value: &matcher.CombinatorNode{Name: "#array-of", Args: []matcher.Node{
+				&matcher.ArrayNode{Items: []matcher.Node{&matcher.LiteralNode{Value: 1.0}, &matcher.MarkerNode{Name: "#bool"}}},
+			}}},
+		},
+		Where:      []string{"len(items) > 0"},
+		Properties: "2..",
+	}
+	p, err := matcher.CompileAST(root)
+	if err != nil {
+		t.Fatalf("CompileAST() error = %v", err)
+	}
+	tests := []struct {
+		name string
+		j    string
+		want bool
+	}{
+		{name: "match", j: `{ "id": "12", "tag": "#literal", "x-a": 1, "items": [ [ 1, true ] ] }`, want: true},
+		{name: "key-pattern", j: `{ "id": "12", "tag": "#literal", "x-a": "1", "items": [ [ 1, true ] ] }`},
+		{name: "where", j: `{ "id": "12", "tag": "#literal", "items": [] }`},
+		{name: "literal", j: `{ "id": "12", "tag": "literal", "items": [ [ 1, false ] ] }`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := p.Matches([]byte(tt.j))
+			if err != nil {
+				t.Fatalf("Matches() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Matches() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	_, err = matcher.CompileAST(&matcher.ArrayNode{Items: []matcher.Node{&matcher.MarkerNode{Name: "#strnig"}}})
+	var patternErr *matcher.PatternError
+	if !errors.As(err, &patternErr) || patternErr.Pointer != "/0" || !errors.Is(err, matcher.ErrUnknownMarker) {
+		t.Errorf("CompileAST() error = %v, want a *PatternError at /0", err)
+	}
+	if _, err := matcher.CompileAST(&matcher.MarkerNode{Name: "uuid"}); !errors.Is(err, matcher.ErrInvalidPattern) {
+		t.Errorf("CompileAST() error = %v, want an ErrInvalidPattern", err)
+	}
+}
@@ -0,0 +1,186 @@
+package matcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// paramMarker is a placeholder for a value supplied with Pattern.Bind, e.g.
+// `#param owner`.
+const paramMarker = "#param"
+
+// getParam checks if `spec` is a `#param NAME` placeholder, returning NAME.
+func getParam(spec interface{}) (string, bool) {
+	isMarker, marker := getMarker(spec)
+	if !isMarker {
+		return "", false
+	}
+	name := strings.TrimPrefix(marker, paramMarker+" ")
+	if name == marker {
+		return "", false
+	}
+	return name, true
+}
+
+// Params returns the sorted names of the `#param` placeholders in the
+// pattern, which must be supplied to Bind.
+func (p *Pattern) Params() []string {
+	set := map[string]bool{}
+	visit := func(spec interface{}) {
+		substituteParams(spec, func(name string) interface{} {
+			set[name] = true
+			return nil
+		})
+	}
+	visit(p.spec)
+	for _, def := range p.defs {
+		visit(def)
+	}
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Bind returns a new pattern replacing the `#param NAME` placeholders with
+// the values in `params`, which must provide all the parameters of the
+// pattern and no others.
+// A value is matched literally: it can be anything which can be encoded as
+// JSON, and strings starting with `#` aren't markers. To supply a pattern
+// instead, pass a *Pattern, whose definitions must not conflict with those
+// of `p` and which must not have parameters itself.
+// The errors match ErrInvalidPattern.
+func (p *Pattern) Bind(params map[string]interface{}) (*Pattern, error) {
+	names := p.Params()
+	var missing, unknown []string
+	for _, name := range names {
+		if _, ok := params[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	for name := range params {
+		if i := sort.SearchStrings(names, name); i == len(names) || names[i] != name {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	switch {
+	case len(missing) > 0:
+		return nil, withSentinel(ErrInvalidPattern, fmt.Errorf("missing parameters: %s", strings.Join(missing, ", ")))
+	case len(unknown) > 0:
+		return nil, withSentinel(ErrInvalidPattern, fmt.Errorf("unknown parameters: %s", strings.Join(unknown, ", ")))
+	}
+
+	values := make(map[string]interface{}, len(params))
+	defs := make(map[string]interface{}, len(p.defs))
+	for name, def := range p.defs {
+		defs[name] = def
+	}
+	for name, value := range params {
+		if pattern, ok := value.(*Pattern); ok {
+			if unbound := pattern.Params(); len(unbound) > 0 {
+				return nil, withSentinel(ErrInvalidPattern, fmt.Errorf("the pattern of parameter %q has unbound parameters: %s",
+					name, strings.Join(unbound, ", ")))
+			}
+			values[name] = pattern.spec
+			for _, defName := range sortedMapKeys(pattern.defs) {
+				def := pattern.defs[defName]
+				if existing, ok := defs[defName]; ok && !reflect.DeepEqual(existing, def) {
+					return nil, withSentinel(ErrInvalidPattern,
+						fmt.Errorf("the definition %q of parameter %q conflicts with the pattern's", defName, name))
+				}
+				defs[defName] = def
+			}
+			continue
+		}
+		literal, err := literalSpec(value)
+		if err != nil {
+			return nil, withSentinel(ErrInvalidPattern, fmt.Errorf("invalid value of parameter %q: %w", name, err))
+		}
+		values[name] = literal
+	}
+
+	lookup := func(name string) interface{} {
+		return values[name]
+	}
+	spec := substituteParams(p.spec, lookup)
+	for name, def := range defs {
+		defs[name] = substituteParams(def, lookup)
+	}
+	return makePattern(spec, defs, p.opts), nil
+}
+
+// literalSpec converts a Go value to a pattern matching it literally.
+func literalSpec(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var spec interface{}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, err
+	}
+	return escapeLiterals(spec), nil
+}
+
+// escapeLiterals escapes the strings and the object keys starting with `#`
+// in `spec`, so that they aren't interpreted as markers or pattern keys.
+func escapeLiterals(spec interface{}) interface{} {
+	switch v := spec.(type) {
+	case string:
+		if strings.HasPrefix(v, markerPrefix) {
+			return markerPrefix + v
+		}
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = escapeLiterals(item)
+		}
+		// an array starting with a marker would be a combinator, so
+		// escaping the strings is enough
+		return items
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			if strings.HasPrefix(key, markerPrefix) {
+				key = markerPrefix + key
+			}
+			m[key] = escapeLiterals(value)
+		}
+		return m
+	}
+	return spec
+}
+
+// substituteParams returns a copy of `spec` with the `#param` placeholders
+// replaced by the values returned by `lookup`. The values of `#enum`
+// patterns are literals, so they are left untouched.
+func substituteParams(spec interface{}, lookup func(name string) interface{}) interface{} {
+	switch v := spec.(type) {
+	case string:
+		if name, ok := getParam(v); ok {
+			return lookup(name)
+		}
+	case []interface{}:
+		if len(v) > 0 && (isMarker(v[0], enumMarker) || isMarker(v[0], enumMarkerI)) {
+			return v
+		}
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = substituteParams(item, lookup)
+		}
+		return items
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			m[key] = substituteParams(value, lookup)
+		}
+		return m
+	}
+	return spec
+}
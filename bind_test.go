@@ -0,0 +1,127 @@
+package matcher_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	matcher "github.com/panta/go-json-matcher"
+)
+
+const bindTestPattern = `{
+  "#defs": { "owner": { "id": "#param owner" } },
+  "id": "#uuid",
+  "type": "#param type",
+  "owner": "#use owner",
+  "tags": "#param tags",
+  "status": [ "#enum", "#param status", "active" ]
+}`
+
+func TestBind(t *testing.T) {
+	p := matcher.MustCompile(bindTestPattern)
+	if got, want := p.Params(), []string{"owner", "tags", "type"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Params() got = %v, want %v", got, want)
+	}
+
+	bound, err := p.Bind(map[string]interface{}{
+		"type":  "#article",
+		"owner": 42,
+		"tags":  matcher.MustCompile(`[ "#array-of", "#string" ]`),
+	})
+	if err != nil {
+		t.Fatalf("Bind() error = %v", err)
+	}
+	if got := bound.Params(); len(got) != 0 {
+		t.Errorf("Params() got = %v, want none", got)
+	}
+
+	tests := []struct {
+		name string
+		j    string
+		want bool
+	}{
+		{name: "match", j: `{ "id": "5f0c3b9e-1a2b-4c3d-8e4f-5a6b7c8d9e0f", "type": "#article", "owner": { "id": 42 },
+  "tags": [ "a" ], "status": "active" }`, want: true},
+		{name: "literal-type", j: `{ "id": "5f0c3b9e-1a2b-4c3d-8e4f-5a6b7c8d9e0f", "type": "article", "owner": { "id": 42 },
+  "tags": [ "a" ], "status": "active" }`, want: false},
+		{name: "owner", j: `{ "id": "5f0c3b9e-1a2b-4c3d-8e4f-5a6b7c8d9e0f", "type": "#article", "owner": { "id": 43 },
+  "tags": [ "a" ], "status": "active" }`, want: false},
+		{name: "tags-pattern", j: `{ "id": "5f0c3b9e-1a2b-4c3d-8e4f-5a6b7c8d9e0f", "type": "#article", "owner": { "id": 42 },
+  "tags": [ 1 ], "status": "active" }`, want: false},
+		{name: "enum-untouched", j: `{ "id": "5f0c3b9e-1a2b-4c3d-8e4f-5a6b7c8d9e0f", "type": "#article",
+  "owner": { "id": 42 }, "tags": [], "status": "#param status" }`, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := bound.Matches([]byte(tt.j))
+			if err != nil {
+				t.Fatalf("Matches() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Matches() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBindObjectLiteral(t *testing.T) {
+	literal := map[string]interface{}{"#where": "a > 1", "#properties": "5", "a": 1}
+	bound, err := matcher.MustCompile(`{ "data": "#param data" }`).Bind(map[string]interface{}{"data": literal})
+	if err != nil {
+		t.Fatalf("Bind() error = %v", err)
+	}
+	report, err := bound.Match([]byte(`{ "data": { "#where": "a > 1", "#properties": "5", "a": 1 } }`))
+	if err != nil {
+		t.Fatalf("Match() error = %v", err)
+	}
+	if !report.Matches() {
+		t.Errorf("Match() got = %v, want no mismatches", report.Mismatches)
+	}
+	if ok, _ := bound.Matches([]byte(`{ "data": { "#where": "a > 1", "a": 1 } }`)); ok {
+		t.Errorf("Matches() got = true, want false for a missing #properties key")
+	}
+}
+
+func TestBindInvalid(t *testing.T) {
+	p := matcher.MustCompile(bindTestPattern)
+	tests := []struct {
+		name    string
+		params  map[string]interface{}
+		wantErr string
+	}{
+		{name: "missing", params: map[string]interface{}{"type": "a"}, wantErr: "missing parameters: owner, tags"},
+		{name: "unknown", params: map[string]interface{}{"type": "a", "owner": 1, "tags": nil, "x": 1, "status": 2},
+			wantErr: "unknown parameters: status, x"},
+		{name: "invalid-value", params: map[string]interface{}{"type": "a", "owner": 1, "tags": func() {}},
+			wantErr: `invalid value of parameter "tags"`},
+		{name: "conflicting-definition", params: map[string]interface{}{"type": "a", "owner": 1,
+			"tags": matcher.MustCompile(`{ "#defs": { "owner": "#string" }, "name": "#use owner" }`)},
+			wantErr: `the definition "owner" of parameter "tags" conflicts with the pattern's`},
+		{name: "unbound-pattern-parameter", params: map[string]interface{}{"type": "a", "owner": 1,
+			"tags": matcher.MustCompile(`{ "#defs": { "tag": "#param tag" }, "name": "#use tag" }`)},
+			wantErr: `the pattern of parameter "tags" has unbound parameters: tag`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := p.Bind(tt.params)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Bind() error = %v, want %q", err, tt.wantErr)
+			}
+			if !errors.Is(err, matcher.ErrInvalidPattern) {
+				t.Errorf("Bind() error = %v, want an ErrInvalidPattern", err)
+			}
+		})
+	}
+
+	var patternErr *matcher.PatternError
+	_, err := matcher.Compile([]byte(`{ "#key #param k": "#string" }`))
+	if !errors.As(err, &patternErr) || patternErr.Pointer != "/#key #param k" {
+		t.Errorf("Compile() error = %v, want a *PatternError at /#key #param k", err)
+	}
+
+	if _, err := p.Match([]byte(`{ "type": "a" }`)); err == nil ||
+		!strings.Contains(err.Error(), `unbound parameter "type"`) {
+		t.Errorf("Match() error = %v, want an unbound parameter error", err)
+	}
+}
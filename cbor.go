@@ -0,0 +1,39 @@
+package matcher
+
+import (
+	"fmt"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// CBORMatches checks if the CBOR (RFC 8949) document in `doc` satisfies the
+// JSON pattern in `jPatternSpecifier`.
+// Integers keep their integer type, byte strings are matched by `#bytes` or
+// by a literal string containing their base64 encoding, and timestamps
+// (tags 0 and 1) are compared as RFC3339 strings in UTC.
+func CBORMatches(doc []byte, jPatternSpecifier []byte) (bool, error) {
+	p, err := Compile(jPatternSpecifier)
+	if err != nil {
+		return false, err
+	}
+	report, err := p.MatchCBOR(doc)
+	if err != nil {
+		return false, err
+	}
+	return report.Matches(), nil
+}
+
+// MatchCBOR checks the CBOR document in `doc` against the pattern and
+// returns a report listing every mismatch found.
+func (p *Pattern) MatchCBOR(doc []byte) (*Report, error) {
+	var x interface{}
+	err := cbor.Unmarshal(doc, &x)
+	if err != nil {
+		return nil, withSentinel(ErrInvalidDocument, fmt.Errorf("can't unmarshal left argument: %w", err))
+	}
+	x, err = normalizeDecoded(x)
+	if err != nil {
+		return nil, withSentinel(ErrInvalidDocument, fmt.Errorf("can't unmarshal left argument: %w", err))
+	}
+	return p.matchValue(x)
+}
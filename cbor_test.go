@@ -0,0 +1,87 @@
+package matcher_test
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+
+	matcher "github.com/panta/go-json-matcher"
+)
+
+func TestCBORMatches(t *testing.T) {
+	bigNum, _ := new(big.Int).SetString("100000000000000000000", 10)
+	tests := []struct {
+		name    string
+		doc     interface{}
+		jSpec   string
+		want    bool
+		wantErr bool
+	}{
+		{name: "int", doc: 42, jSpec: `42`, want: true},
+		{name: "negative-int", doc: -42, jSpec: `-42.0`, want: true},
+		{name: "int-ne", doc: 42, jSpec: `43`, want: false},
+		{name: "uint64-exact", doc: uint64(18446744073709551615), jSpec: `"#integer"`, want: true},
+		{name: "int-integer", doc: 42, jSpec: `"#integer"`, want: true},
+		{name: "int-not-float", doc: 42, jSpec: `"#float"`, want: false},
+		{name: "float-float", doc: 42.0, jSpec: `"#float"`, want: true},
+		{name: "float-number", doc: 4.5, jSpec: `"#number"`, want: true},
+		{name: "float32", doc: float32(4.5), jSpec: `4.5`, want: true},
+		{name: "bignum", doc: bigNum, jSpec: `1e20`, want: true},
+		{name: "bytes", doc: []byte("hello"), jSpec: `"#bytes"`, want: true},
+		{name: "bytes-base64", doc: []byte("hello"), jSpec: `"aGVsbG8="`, want: true},
+		{name: "bytes-base64-raw", doc: []byte("hello"), jSpec: `"aGVsbG8"`, want: true},
+		{name: "bytes-base64-url", doc: []byte{0xfb, 0xff}, jSpec: `"-_8="`, want: true},
+		{name: "bytes-base64-ne", doc: []byte("hello"), jSpec: `"aGVsbG9="`, want: false},
+		{name: "bytes-not-string", doc: []byte("hello"), jSpec: `"#string"`, want: false},
+		{name: "bytes-not-array", doc: []byte("hello"), jSpec: `"#array"`, want: false},
+		{name: "string-not-bytes", doc: "hello", jSpec: `"#bytes"`, want: false},
+		{name: "time", doc: time.Date(2022, 7, 20, 9, 56, 29, 0, time.UTC), jSpec: `"#datetime"`, want: true},
+		{name: "time-literal", doc: time.Date(2022, 7, 20, 9, 56, 29, 0, time.UTC),
+			jSpec: `"2022-07-20T09:56:29Z"`, want: true},
+		{name: "time-epoch", doc: cbor.Tag{Number: 1, Content: 1658310989}, jSpec: `"2022-07-20T09:56:29Z"`, want: true},
+		{name: "unknown-tag", doc: cbor.Tag{Number: 32, Content: "https://example.com"}, jSpec: `"#string"`, want: true},
+		{name: "map-non-string-keys", doc: map[interface{}]interface{}{1: "one", "two": 2},
+			jSpec: `{ "1": "one", "two": 2 }`, want: true},
+		{name: "nested", doc: map[string]interface{}{
+			"id":    uint64(7),
+			"data":  []byte{1, 2, 3},
+			"tags":  []string{"a", "b"},
+			"ratio": 0.5,
+			"none":  nil,
+		}, jSpec: `{ "id": "#integer", "data": "#bytes", "tags": [ "#array-of", "#string" ], "ratio": "#float",
+"none": "#null", "error": "#notpresent" }`, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encMode, err := cbor.EncOptions{Time: cbor.TimeRFC3339, TimeTag: cbor.EncTagRequired}.EncMode()
+			if err != nil {
+				t.Fatalf("cbor.EncOptions.EncMode() error = %v", err)
+			}
+			doc, err := encMode.Marshal(tt.doc)
+			if err != nil {
+				t.Fatalf("cbor.Marshal() error = %v", err)
+			}
+			got, err := matcher.CBORMatches(doc, []byte(tt.jSpec))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CBORMatches() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("CBORMatches() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCBORMatchesInvalid(t *testing.T) {
+	_, err := matcher.CBORMatches([]byte{0xff, 0x00}, []byte(`"#ignore"`))
+	if err == nil {
+		t.Errorf("CBORMatches() expected error for invalid document")
+	}
+	_, err = matcher.CBORMatches([]byte{0x01}, []byte(`[A237`))
+	if err == nil {
+		t.Errorf("CBORMatches() expected error for invalid pattern")
+	}
+}
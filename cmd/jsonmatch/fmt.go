@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	matcher "github.com/panta/go-json-matcher"
+)
+
+// isFmtFile checks if the file `name` is one rewritten by the fmt command:
+// JSON and relaxed JSON pattern files.
+func isFmtFile(name string) bool {
+	ext := filepath.Ext(name)
+	return ext == ".json" || ext == ".json5"
+}
+
+func runFmt(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	list := flags.Bool("l", false, "list the files whose formatting differs from Format's")
+	write := flags.Bool("w", false, "write the result to the files instead of the standard output")
+	sortKeys := flags.Bool("sort-keys", false, "sort the keys of objects")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	opts := matcher.FormatOptions{SortKeys: *sortKeys}
+
+	if flags.NArg() == 0 {
+		if *write {
+			fmt.Fprintln(stderr, "jsonmatch: can't use -w with the standard input")
+			return 2
+		}
+		src, err := io.ReadAll(stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, "jsonmatch: %v\n", err)
+			return 2
+		}
+		out, err := matcher.FormatWithOptions(src, opts)
+		if err != nil {
+			fmt.Fprintf(stderr, "jsonmatch: <standard input>: %v\n", err)
+			return 2
+		}
+		if *list {
+			if !bytes.Equal(src, out) {
+				fmt.Fprintln(stdout, "<standard input>")
+				return 1
+			}
+			return 0
+		}
+		_, _ = stdout.Write(out)
+		return 0
+	}
+
+	status := 0
+	for _, root := range flags.Args() {
+		err := walkFiles(root, isFmtFile, func(path string) error {
+			changed, err := fmtFile(path, opts, *list, *write, stdout)
+			if changed && *list {
+				status = 1
+			}
+			return err
+		})
+		if err != nil {
+			fmt.Fprintf(stderr, "jsonmatch: %v\n", err)
+			return 2
+		}
+	}
+	return status
+}
+
+// fmtFile formats the pattern file at `path`, listing its name when `list` is
+// true and its formatting differs, writing the result back when `write` is
+// true or printing it otherwise. It returns whether the formatting differs.
+func fmtFile(path string, opts matcher.FormatOptions, list bool, write bool, stdout io.Writer) (bool, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	out, err := matcher.FormatWithOptions(src, opts)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", path, err)
+	}
+	changed := !bytes.Equal(src, out)
+	if list && changed {
+		fmt.Fprintln(stdout, path)
+	}
+	if write {
+		if !changed {
+			return false, nil
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return changed, err
+		}
+		return changed, os.WriteFile(path, out, info.Mode().Perm())
+	}
+	if !list {
+		_, err = stdout.Write(out)
+	}
+	return changed, err
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+
+	matcher "github.com/panta/go-json-matcher"
+)
+
+const matcherImportPath = "github.com/panta/go-json-matcher"
+
+// patternFunc describes a function of the package taking a pattern.
+type patternFunc struct {
+	arg  int // the index of the pattern argument
+	lint lintFunc
+}
+
+//nolint:gochecknoglobals // an internal global here is clearer than a function returning a constant map
+var patternFuncs = map[string]patternFunc{
+	"Compile":            {arg: 0, lint: matcher.Lint},
+	"MustCompile":        {arg: 0, lint: matcher.Lint},
+	"CompileRelaxed":     {arg: 0, lint: matcher.LintRelaxed},
+	"MustCompileRelaxed": {arg: 0, lint: matcher.LintRelaxed},
+	"CompileYAML":        {arg: 0, lint: matcher.LintYAML},
+	"JSONMatches":        {arg: 1, lint: matcher.Lint},
+	"JSONStringMatches":  {arg: 1, lint: matcher.Lint},
+	"CBORMatches":        {arg: 1, lint: matcher.Lint},
+	"MsgPackMatches":     {arg: 1, lint: matcher.Lint},
+	"ProtoMatches":       {arg: 1, lint: matcher.Lint},
+	"YAMLMatches":        {arg: 1, lint: matcher.LintYAML},
+}
+
+// goPattern is a pattern written as a string literal in a Go file.
+type goPattern struct {
+	src  string
+	lint lintFunc
+	// the position of the literal in the file
+	line   int
+	column int
+	// whether the literal is a raw string, whose content maps one to one to
+	// the pattern source
+	raw bool
+}
+
+// filePosition converts a position in the pattern to a position in the file.
+// For interpreted string literals, where escape sequences shift the
+// positions, it returns the position of the literal.
+func (p goPattern) filePosition(line int, column int) (int, int) {
+	switch {
+	case !p.raw || line == 0:
+		return p.line, p.column
+	case line == 1:
+		// skip the opening backquote
+		return p.line, p.column + column
+	}
+	return p.line + line - 1, column
+}
+
+// findGoPatterns returns the string literals passed as patterns to the
+// functions of the package in the Go source `src`. Named constants declared
+// in the same file are resolved as well.
+func findGoPatterns(filename string, src []byte) ([]goPattern, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, 0)
+	if err != nil {
+		return nil, err
+	}
+	qualifier, ok := matcherQualifier(file)
+	if !ok {
+		return nil, nil
+	}
+
+	var patterns []goPattern
+	ast.Inspect(file, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		f, ok := patternFuncs[calledName(call, qualifier)]
+		if !ok || f.arg >= len(call.Args) {
+			return true
+		}
+		lit := stringLiteral(call.Args[f.arg])
+		if lit == nil {
+			return true
+		}
+		value, err := strconv.Unquote(lit.Value)
+		if err != nil {
+			return true
+		}
+		pos := fset.Position(lit.Pos())
+		patterns = append(patterns, goPattern{src: value, lint: f.lint, line: pos.Line, column: pos.Column,
+			raw: strings.HasPrefix(lit.Value, "`")})
+		return true
+	})
+	return patterns, nil
+}
+
+// matcherQualifier returns the name the file uses to refer to the package,
+// which is empty for the files of the package itself.
+func matcherQualifier(file *ast.File) (string, bool) {
+	if file.Name.Name == "matcher" {
+		return "", true
+	}
+	for _, spec := range file.Imports {
+		if path, err := strconv.Unquote(spec.Path.Value); err != nil || path != matcherImportPath {
+			continue
+		}
+		if spec.Name != nil {
+			return spec.Name.Name, spec.Name.Name != "_" && spec.Name.Name != "."
+		}
+		return "matcher", true
+	}
+	return "", false
+}
+
+// calledName returns the name of the function called by `call`, if it is a
+// function of the package referred to with `qualifier`.
+func calledName(call *ast.CallExpr, qualifier string) string {
+	switch fun := call.Fun.(type) {
+	case *ast.Ident:
+		if qualifier == "" {
+			return fun.Name
+		}
+	case *ast.SelectorExpr:
+		if x, ok := fun.X.(*ast.Ident); ok && qualifier != "" && x.Name == qualifier {
+			return fun.Sel.Name
+		}
+	}
+	return ""
+}
+
+// stringLiteral returns the string literal in `expr`, looking through
+// conversions (e.g. `[]byte("...")`), parentheses and constants declared in
+// the same file.
+func stringLiteral(expr ast.Expr) *ast.BasicLit {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		if e.Kind == token.STRING {
+			return e
+		}
+	case *ast.ParenExpr:
+		return stringLiteral(e.X)
+	case *ast.CallExpr:
+		if _, ok := e.Fun.(*ast.ArrayType); ok && len(e.Args) == 1 {
+			return stringLiteral(e.Args[0])
+		}
+	case *ast.Ident:
+		//nolint:staticcheck // the file-local resolution is enough for constants declared in the same file
+		if e.Obj == nil || e.Obj.Kind != ast.Con {
+			return nil
+		}
+		spec, ok := e.Obj.Decl.(*ast.ValueSpec)
+		if !ok {
+			return nil
+		}
+		for i, name := range spec.Names {
+			if name.Name == e.Name && i < len(spec.Values) {
+				return stringLiteral(spec.Values[i])
+			}
+		}
+	}
+	return nil
+}
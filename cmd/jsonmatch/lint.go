@@ -0,0 +1,127 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	matcher "github.com/panta/go-json-matcher"
+)
+
+// lintFunc lints a pattern in one of the supported syntaxes.
+type lintFunc func(pattern []byte, opts ...matcher.Option) []matcher.Diagnostic
+
+//nolint:gochecknoglobals // an internal global here is clearer than a function returning a constant map
+var patternFileLinters = map[string]lintFunc{
+	".json":  matcher.Lint,
+	".json5": matcher.LintRelaxed,
+	".yaml":  matcher.LintYAML,
+	".yml":   matcher.LintYAML,
+}
+
+func runLint(args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	prefix := flags.String("marker-prefix", "#", "the prefix of the markers (see matcher.WithMarkerPrefix)")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	opts := []matcher.Option{matcher.WithMarkerPrefix(*prefix)}
+
+	status := 0
+	for _, root := range paths {
+		err := walkFiles(root, isLintFile, func(path string) error {
+			diagnostics, err := lintFile(path, opts)
+			if err != nil {
+				return err
+			}
+			for _, d := range diagnostics {
+				fmt.Fprintf(stdout, "%s:%s\n", path, d)
+				status = 1
+			}
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintf(stderr, "jsonmatch: %v\n", err)
+			return 2
+		}
+	}
+	return status
+}
+
+// isLintFile checks if the file `name` is one checked by the lint command:
+// pattern files and Go test files.
+func isLintFile(name string) bool {
+	_, ok := patternFileLinters[filepath.Ext(name)]
+	return ok || strings.HasSuffix(name, "_test.go")
+}
+
+// walkFiles calls `visit` with `root`, if it is a file, or with the files in
+// the `root` directory for which `include` is true, skipping hidden and
+// vendor directories.
+func walkFiles(root string, include func(name string) bool, visit func(path string) error) error {
+	info, err := os.Stat(root)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return visit(root)
+	}
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if path != root && (strings.HasPrefix(name, ".") || name == "vendor") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if include(name) {
+			return visit(path)
+		}
+		return nil
+	})
+}
+
+// lintFile lints the pattern file or the Go file at `path`.
+func lintFile(path string, opts []matcher.Option) ([]matcher.Diagnostic, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if filepath.Ext(path) == ".go" {
+		return lintGoFile(path, src, opts)
+	}
+	lint, ok := patternFileLinters[filepath.Ext(path)]
+	if !ok {
+		return nil, fmt.Errorf("%s: unsupported file type", path)
+	}
+	return lint(src, opts...), nil
+}
+
+// lintGoFile lints the patterns found in the Go source `src`, translating
+// the positions of the diagnostics to positions in the file.
+func lintGoFile(path string, src []byte, opts []matcher.Option) ([]matcher.Diagnostic, error) {
+	patterns, err := findGoPatterns(path, src)
+	if err != nil {
+		return nil, err
+	}
+	var diagnostics []matcher.Diagnostic
+	for _, p := range patterns {
+		for _, d := range p.lint([]byte(p.src), opts...) {
+			d.Line, d.Column = p.filePosition(d.Line, d.Column)
+			diagnostics = append(diagnostics, d)
+		}
+	}
+	return diagnostics, nil
+}
@@ -0,0 +1,56 @@
+// Command jsonmatch checks go-json-matcher patterns.
+//
+// Usage:
+//
+//	jsonmatch lint [-marker-prefix PREFIX] [PATH ...]
+//	jsonmatch fmt [-l] [-w] [-sort-keys] [PATH ...]
+//
+// The lint command reports the mistakes found by matcher.Lint in pattern
+// files (.json, .json5, .yaml and .yml) and in the patterns passed as string
+// literals to the functions of the package (e.g. matcher.MustCompile) in Go
+// files. Directories are scanned recursively, looking at Go test files only;
+// the default path is the current directory.
+//
+// The fmt command rewrites pattern files (.json and .json5) in the layout
+// produced by matcher.Format, printing the result. Like gofmt, -l lists the
+// files whose formatting differs (exiting with status 1 if any) and -w
+// writes the result back to the files. Directories are scanned recursively;
+// without paths, the pattern is read from the standard input.
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+const usage = `usage: jsonmatch <command> [arguments]
+
+commands:
+  lint    report mistakes in pattern files and in the patterns of Go files
+  fmt     format pattern files
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run executes the command in `args`, returning the exit status: 0 on
+// success, 1 when problems are found and 2 for invalid usage or I/O errors.
+func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	switch args[0] {
+	case "lint":
+		return runLint(args[1:], stdout, stderr)
+	case "fmt":
+		return runFmt(args[1:], stdin, stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return 0
+	}
+	fmt.Fprintf(stderr, "jsonmatch: unknown command %q\n%s", args[0], usage)
+	return 2
+}
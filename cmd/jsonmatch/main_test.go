@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLintCommand(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"patterns/user.json":  "{\n  \"id\": \"#uuid\",\n  \"name\": \"#strnig\"\n}\n",
+		"patterns/order.yaml": "id: \"#uuid\"\nstatus: \"#enum new|paid|new\"\n",
+		"patterns/ok.json5":   "{ id: '#uuid', /* trailing comma */ }\n",
+		"patterns/README.md":  "not a pattern",
+		"api_test.go": "package api_test\n\nimport m \"github.com/panta/go-json-matcher\"\n\n" +
+			"const userPattern = `{\n  \"id\": \"#uuid\",\n  \"gone\": [ \"#notpresent\" ]\n}`\n\n" +
+			"var (\n\tp1 = m.MustCompile(userPattern)\n\tp2, _ = m.Compile([]byte(`{ \"id\": \"#regex [0-9]+\" }`))\n" +
+			"\tp3, _ = m.JSONStringMatches(`{}`, \"{ \\\"a\\\": \\\"#nope\\\" }\")\n)\n",
+		"api.go":              "package api\n\nconst ignored = `{ \"a\": \"#nope\" }`\n",
+		".hidden/broken.json": "{",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	if status := run([]string{"lint", dir}, nil, &stdout, &stderr); status != 1 {
+		t.Errorf("run() = %d, want 1 (stderr: %s)", status, stderr.String())
+	}
+	want := filepath.Join(dir, "api_test.go") + `:7:13: error: #notpresent only matches a missing object key, ` +
+		`so it can never match here (notpresent-outside-object)
+` + filepath.Join(dir, "api_test.go") + `:12:36: warning: regular expression "[0-9]+" matches any string containing ` +
+		`a match, anchor it with ^ and $ (unanchored-regex)
+` + filepath.Join(dir, "api_test.go") + `:13:36: error: unsupported pattern '#nope' (unknown-marker)
+` + filepath.Join(dir, "patterns/order.yaml") + `:2:9: warning: enum value "new" is unreachable, it repeats "new" ` +
+		`(unreachable-enum-value)
+` + filepath.Join(dir, "patterns/user.json") + `:3:11: error: unsupported pattern '#strnig' (unknown-marker)
+`
+	if stdout.String() != want {
+		t.Errorf("run() output =\n%s\nwant =\n%s", stdout.String(), want)
+	}
+
+	stdout.Reset()
+	if status := run([]string{"lint", filepath.Join(dir, "patterns/ok.json5")}, nil, &stdout, &stderr); status != 0 {
+		t.Errorf("run() = %d, want 0 (output: %s)", status, stdout.String())
+	}
+	if status := run([]string{"lint", filepath.Join(dir, "missing.json")}, nil, &stdout, &stderr); status != 2 {
+		t.Errorf("run() = %d, want 2", status)
+	}
+	if status := run([]string{"frobnicate"}, nil, &stdout, &stderr); status != 2 {
+		t.Errorf("run() = %d, want 2", status)
+	}
+}
+
+func TestFmtCommand(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.json":     "{\"ok\":\"#bool\"}\n",
+		"b.json5":    "{\n  // flag\n  \"ok\": \"#boolean\"\n}\n",
+		"c.yaml":     "ok: \"#bool\"\n",
+		"sub/d.json": "[1,2]",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	if status := run([]string{"fmt", "-l", dir}, nil, &stdout, &stderr); status != 1 {
+		t.Errorf("run() = %d, want 1 (stderr: %s)", status, stderr.String())
+	}
+	want := filepath.Join(dir, "a.json") + "\n" + filepath.Join(dir, "sub/d.json") + "\n"
+	if stdout.String() != want {
+		t.Errorf("run() output =\n%s\nwant =\n%s", stdout.String(), want)
+	}
+
+	stdout.Reset()
+	if status := run([]string{"fmt", "-w", dir}, nil, &stdout, &stderr); status != 0 {
+		t.Errorf("run() = %d, want 0 (stderr: %s)", status, stderr.String())
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "a.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\n  \"ok\": \"#boolean\"\n}\n"; string(got) != want {
+		t.Errorf("formatted file =\n%s\nwant =\n%s", got, want)
+	}
+	if status := run([]string{"fmt", "-l", dir}, nil, &stdout, &stderr); status != 0 || stdout.Len() > 0 {
+		t.Errorf("run() = %d, want 0 with no output (output: %s)", status, stdout.String())
+	}
+
+	stdin := bytes.NewBufferString(`{"b":1,"a":"#bool"}`)
+	if status := run([]string{"fmt", "-sort-keys"}, stdin, &stdout, &stderr); status != 0 {
+		t.Errorf("run() = %d, want 0 (stderr: %s)", status, stderr.String())
+	}
+	if want := "{\n  \"a\": \"#boolean\",\n  \"b\": 1\n}\n"; stdout.String() != want {
+		t.Errorf("run() output =\n%s\nwant =\n%s", stdout.String(), want)
+	}
+	if status := run([]string{"fmt"}, bytes.NewBufferString("{"), &stdout, &stderr); status != 2 {
+		t.Errorf("run() = %d, want 2", status)
+	}
+}
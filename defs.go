@@ -0,0 +1,206 @@
+package matcher
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const (
+	// defsKey is the key of the root object pattern holding the named pattern
+	// definitions, e.g. `"#defs": { "user": { "id": "#uuid" } }`.
+	defsKey = "#defs"
+	// useMarker references a named pattern definition, e.g. `#use user`.
+	useMarker = "#use"
+)
+
+// PatternLibrary is a set of named patterns, which can be referenced with
+// `#use NAME` by the patterns compiled with the WithLibrary option.
+// The patterns in a library can reference each other.
+type PatternLibrary struct {
+	defs map[string]interface{}
+	opts options // the options of the patterns returned by Pattern
+}
+
+// NewPatternLibrary returns an empty PatternLibrary.
+func NewPatternLibrary() *PatternLibrary {
+	o, _ := newOptions(nil)
+	return &PatternLibrary{defs: map[string]interface{}{}, opts: o}
+}
+
+// Define adds the pattern `p` to the library with the name `name`, together
+// with the definitions in its `#defs` section (if any).
+// An error is returned if a name is already defined with a different pattern.
+// References are checked when compiling the patterns using the library.
+func (l *PatternLibrary) Define(name string, p *Pattern) error {
+	if err := l.define(name, p.spec); err != nil {
+		return err
+	}
+	for _, defName := range sortedMapKeys(p.defs) {
+		if err := l.define(defName, p.defs[defName]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *PatternLibrary) define(name string, spec interface{}) error {
+	if existing, ok := l.defs[name]; ok {
+		if reflect.DeepEqual(existing, spec) {
+			// e.g. a definition of the library itself, used by `p`
+			return nil
+		}
+		return fmt.Errorf("pattern %q is already defined", name)
+	}
+	l.defs[name] = spec
+	return nil
+}
+
+// WithLibrary makes the patterns in `lib` available to `#use` references.
+// The definitions are copied when compiling, so later changes to the library
+// don't affect the compiled pattern. Definitions in the `#defs` section of
+// the pattern shadow those in the library with the same name.
+func WithLibrary(lib *PatternLibrary) Option {
+	return func(o *options) {
+		o.library = lib
+	}
+}
+
+// extractDefs removes the `#defs` section from the root of `spec`, returning
+// the definitions merged with those of `lib`.
+func extractDefs(spec interface{}, lib *PatternLibrary) (interface{}, map[string]interface{}, error) {
+	defs := map[string]interface{}{}
+	if lib != nil {
+		for name, def := range lib.defs {
+			defs[name] = def
+		}
+	}
+
+	root, ok := spec.(map[string]interface{})
+	if !ok {
+		return spec, defs, nil
+	}
+	section, ok := root[defsKey]
+	if !ok {
+		return spec, defs, nil
+	}
+	sectionMap, ok := section.(map[string]interface{})
+	if !ok {
+		return nil, nil, fmt.Errorf("%s must be an object, got %s", defsKey, formatValue(section))
+	}
+	for name, def := range sectionMap {
+		defs[name] = def
+	}
+	rest := make(map[string]interface{}, len(root)-1)
+	for key, value := range root {
+		if key != defsKey {
+			rest[key] = value
+		}
+	}
+	return rest, defs, nil
+}
+
+// checkDefs checks that all the `#use` references in the pattern and in the
+// definitions refer to existing definitions, and that there are no cycles
+// of references which can never terminate, i.e. definitions which resolve to
+// themselves without matching any part of the document.
+func checkDefs(spec interface{}, defs map[string]interface{}) error {
+	if err := checkUses(spec, defs); err != nil {
+		return err
+	}
+	for _, name := range sortedMapKeys(defs) {
+		if err := checkUses(defs[name], defs); err != nil {
+			return fmt.Errorf("in definition %q: %w", name, err)
+		}
+	}
+	return checkCycles(defs)
+}
+
+// checkUses checks that all the `#use` references in `spec` refer to
+// existing definitions.
+func checkUses(spec interface{}, defs map[string]interface{}) error {
+	var err error
+	visitUses(spec, func(name string) {
+		if _, ok := defs[name]; !ok && err == nil {
+			err = fmt.Errorf("undefined pattern %q", name)
+		}
+	})
+	return err
+}
+
+// checkCycles checks that no definition resolves to itself through a chain
+// of references. References nested in objects or arrays are allowed to form
+// cycles (e.g. `"a": { "x": "#use b" }, "b": { "y": "#use a" }`): they are
+// resolved while matching a deeper level of the document, so matching
+// always terminates.
+func checkCycles(defs map[string]interface{}) error {
+	for _, name := range sortedMapKeys(defs) {
+		chain := []string{name}
+		for next, ok := getUse(defs[name]); ok; next, ok = getUse(defs[next]) {
+			for _, seen := range chain {
+				if seen == next {
+					return fmt.Errorf("cycle of pattern references: %s",
+						strings.Join(append(chain, next), " -> "))
+				}
+			}
+			chain = append(chain, next)
+		}
+	}
+	return nil
+}
+
+// getUse checks if `spec` is a `#use NAME` reference, returning NAME.
+func getUse(spec interface{}) (string, bool) {
+	isMarker, marker := getMarker(spec)
+	if !isMarker {
+		return "", false
+	}
+	name := strings.TrimPrefix(marker, useMarker+" ")
+	if name == marker {
+		return "", false
+	}
+	return name, true
+}
+
+// visitUses calls `visit` with the name of every `#use` reference in `spec`.
+func visitUses(spec interface{}, visit func(name string)) {
+	switch v := spec.(type) {
+	case string:
+		if name, ok := getUse(v); ok {
+			visit(name)
+		}
+	case []interface{}:
+		if len(v) > 0 && (isMarker(v[0], enumMarker) || isMarker(v[0], enumMarkerI)) {
+			return
+		}
+		for _, item := range v {
+			visitUses(item, visit)
+		}
+	case map[string]interface{}:
+		for _, key := range sortedMapKeys(v) {
+			if key == whereKey {
+				continue
+			}
+			if isKeyPattern, keyPattern := getKeyPattern(key); isKeyPattern {
+				visitUses(keyPattern, visit)
+			}
+			visitUses(v[key], visit)
+		}
+	}
+}
+
+// resolveUse returns the definition referenced by `spec` if it is a `#use`
+// reference, following chains of references.
+func resolveUse(spec interface{}, defs map[string]interface{}) (interface{}, error) {
+	for {
+		name, ok := getUse(spec)
+		if !ok {
+			return spec, nil
+		}
+		def, ok := defs[name]
+		if !ok {
+			return nil, fmt.Errorf("undefined pattern %q", name)
+		}
+		spec = def
+	}
+}
@@ -0,0 +1,154 @@
+package matcher_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	matcher "github.com/panta/go-json-matcher"
+)
+
+const defsTestPattern = `{
+  "#defs": {
+    "money": { "amount": "#number", "currency": "#enum EUR|USD" },
+    "user": { "id": "#uuid", "name": "#string" },
+    "comment": { "author": "#use user", "text": "#string", "replies": [ "#array-of", "#use comment" ] },
+    "price": "#use money"
+  },
+  "owner": "#use user",
+  "total": "#use price",
+  "thread": "#use comment"
+}`
+
+func TestDefs(t *testing.T) {
+	p := matcher.MustCompile(defsTestPattern)
+
+	tests := []struct {
+		name string
+		j    string
+		want []matcher.Mismatch
+	}{
+		{name: "match", j: `{
+  "owner": { "id": "5f0c3b9e-1a2b-4c3d-8e4f-5a6b7c8d9e0f", "name": "joe" },
+  "total": { "amount": 12.5, "currency": "EUR" },
+  "thread": {
+    "author": { "id": "5f0c3b9e-1a2b-4c3d-8e4f-5a6b7c8d9e0f", "name": "joe" },
+    "text": "first",
+    "replies": [
+      { "author": { "id": "6a1d4c0f-2b3c-4d4e-9f50-6b7c8d9e0f1a", "name": "ann" }, "text": "second", "replies": [] }
+    ]
+  }
+}`, want: []matcher.Mismatch{}},
+		{name: "mismatch", j: `{
+  "owner": { "id": "42", "name": "joe" },
+  "total": { "amount": 12.5, "currency": "GBP" },
+  "thread": {
+    "author": { "id": "5f0c3b9e-1a2b-4c3d-8e4f-5a6b7c8d9e0f", "name": "joe" },
+    "text": "first",
+    "replies": [ { "author": { "id": "6a1d4c0f-2b3c-4d4e-9f50-6b7c8d9e0f1a", "name": 7 }, "text": "second" } ]
+  }
+}`, want: []matcher.Mismatch{
+			{Path: "/owner/id", Reason: `"42" doesn't match "#uuid"`},
+			{Path: "/thread/replies/0/author/name", Reason: `7 doesn't match "#string"`},
+			{Path: "/thread/replies/0/replies", Reason: `missing key, expected ["#array-of","#use comment"]`},
+			{Path: "/total/currency", Reason: `expected one of "EUR", "USD", got "GBP"`},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := p.Match([]byte(tt.j))
+			if err != nil {
+				t.Fatalf("Match() error = %v", err)
+			}
+			if !reflect.DeepEqual(report.Mismatches, tt.want) {
+				t.Errorf("Match() got = %v, want %v", report.Mismatches, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefsNestedRecursion(t *testing.T) {
+	// references nested in objects are allowed to form cycles: each one
+	// matches a deeper level of the document, so matching always terminates
+	p, err := matcher.Compile([]byte(`{ "#defs": { "a": { "x": "#use b" }, "b": { "y": "#use a" } }, "root": "#use a" }`))
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	report, err := p.Match([]byte(`{ "root": { "x": { "y": { "x": 1 } } } }`))
+	if err != nil {
+		t.Fatalf("Match() error = %v", err)
+	}
+	want := []matcher.Mismatch{{Path: "/root/x/y/x", Reason: `expected {"y":"#use a"}, got 1`}}
+	if !reflect.DeepEqual(report.Mismatches, want) {
+		t.Errorf("Match() got = %v, want %v", report.Mismatches, want)
+	}
+}
+
+func TestDefsInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		jSpec   string
+		wantErr string
+	}{
+		{name: "undefined", jSpec: `{ "a": "#use user" }`, wantErr: `undefined pattern "user"`},
+		{name: "undefined-in-definition", jSpec: `{ "#defs": { "a": { "b": "#use b" } }, "x": "#use a" }`,
+			wantErr: `in definition "a": undefined pattern "b"`},
+		{name: "self-cycle", jSpec: `{ "#defs": { "a": "#use a" }, "x": 1 }`,
+			wantErr: `cycle of pattern references: a -> a`},
+		{name: "cycle", jSpec: `{ "#defs": { "a": "#use b", "b": "#use c", "c": "#use a" }, "x": 1 }`,
+			wantErr: `cycle of pattern references: a -> b -> c -> a`},
+		{name: "not-an-object", jSpec: `{ "#defs": [ 1 ] }`, wantErr: `#defs must be an object, got [1]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := matcher.Compile([]byte(tt.jSpec))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Compile() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPatternLibrary(t *testing.T) {
+	lib := matcher.NewPatternLibrary()
+	if _, err := matcher.Compile([]byte(`{ "currency": "#use currency" }`), matcher.WithLibrary(lib)); err == nil {
+		t.Errorf("Compile() expected an error for an undefined reference")
+	}
+	if err := lib.Define("currency", matcher.MustCompile(`"#enum EUR|USD"`)); err != nil {
+		t.Fatalf("Define() error = %v", err)
+	}
+	if err := lib.Define("money", matcher.MustCompile(`{ "amount": "#number", "currency": "#use currency" }`,
+		matcher.WithLibrary(lib))); err != nil {
+		t.Fatalf("Define() error = %v", err)
+	}
+	if err := lib.Define("currency", matcher.MustCompile(`"#string"`)); err == nil {
+		t.Errorf("Define() expected an error for a duplicate definition")
+	}
+
+	p := matcher.MustCompile(`{ "price": "#use money", "#defs": { "currency": "#enum GBP" } }`, matcher.WithLibrary(lib))
+	ok, err := p.Matches([]byte(`{ "price": { "amount": 1, "currency": "GBP" } }`))
+	if err != nil || !ok {
+		t.Errorf("Matches() got = %v, %v, want true, nil", ok, err)
+	}
+}
+
+func TestDefsDiff(t *testing.T) {
+	p := matcher.MustCompile(`{ "#defs": { "user": { "id": "#uuid", "name": "#string" } }, "owner": "#use user" }`)
+	report, err := p.Match([]byte(`{ "owner": { "id": "1", "name": "joe" } }`))
+	if err != nil {
+		t.Fatalf("Match() error = %v", err)
+	}
+	want := `--- pattern
++++ document
+  {
+    "owner": {
+-     "id": "#uuid",
++     "id": "1",
+      "name": "joe"
+    }
+  }
+`
+	if got := report.Diff(matcher.DiffOptions{}); got != want {
+		t.Errorf("Diff() got =\n%s\nwant =\n%s", got, want)
+	}
+}
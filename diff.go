@@ -0,0 +1,385 @@
+package matcher
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// DiffStyle selects the layout of a diff rendered by Report.Diff.
+type DiffStyle int
+
+const (
+	// DiffUnified renders the diff like `git diff`: expected (pattern) lines
+	// are prefixed by "-", actual (document) lines by "+".
+	DiffUnified DiffStyle = iota
+	// DiffSideBySide renders the pattern on the left and the document on the
+	// right, marking the rows which differ.
+	DiffSideBySide
+)
+
+// ColorMode controls the use of ANSI colours in rendered diffs.
+type ColorMode int
+
+const (
+	// ColorAuto enables colours only when writing to a terminal, and the
+	// NO_COLOR environment variable is not set.
+	ColorAuto ColorMode = iota
+	// ColorNever disables colours.
+	ColorNever
+	// ColorAlways enables colours.
+	ColorAlways
+)
+
+// DiffOptions configures how a diff is rendered.
+type DiffOptions struct {
+	Style DiffStyle
+	Color ColorMode
+	// MaxMatchingRun is the maximum number of consecutive matching entries of
+	// an object or array which are shown before being collapsed in a single
+	// "…" line. Zero means the default (3).
+	MaxMatchingRun int
+}
+
+const (
+	defaultMaxMatchingRun = 3
+
+	ansiRed   = "\x1b[31m"
+	ansiGreen = "\x1b[32m"
+	ansiReset = "\x1b[0m"
+)
+
+// Diff renders the document the report refers to next to the pattern,
+// highlighting the values which don't match. Subtrees which match entirely
+// are collapsed to keep the output short.
+// With ColorAuto no colours are used, since the destination is unknown: use
+// WriteDiff to enable them when writing to a terminal.
+func (r *Report) Diff(opts DiffOptions) string {
+	var sb strings.Builder
+	r.writeDiff(&sb, opts, opts.Color == ColorAlways)
+	return sb.String()
+}
+
+// WriteDiff writes the diff rendered by Diff to `w`. With ColorAuto,
+// colours are used only if `w` is a terminal.
+func (r *Report) WriteDiff(w io.Writer, opts DiffOptions) error {
+	color := opts.Color == ColorAlways
+	if opts.Color == ColorAuto {
+		color = isTerminal(w)
+	}
+	var sb strings.Builder
+	r.writeDiff(&sb, opts, color)
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// isTerminal tells whether `w` is a terminal and the user didn't opt out of
+// colours with NO_COLOR (see https://no-color.org).
+func isTerminal(w io.Writer) bool {
+	if _, noColor := os.LookupEnv("NO_COLOR"); noColor {
+		return false
+	}
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	// other character devices, like /dev/null, aren't terminals
+	return term.IsTerminal(int(f.Fd()))
+}
+
+func (r *Report) writeDiff(sb *strings.Builder, opts DiffOptions, color bool) {
+	d := differ{
+		defs:           r.defs,
+		mismatches:     map[string]bool{},
+		ancestors:      map[string]bool{},
+		maxMatchingRun: opts.MaxMatchingRun,
+	}
+	if d.maxMatchingRun <= 0 {
+		d.maxMatchingRun = defaultMaxMatchingRun
+	}
+	for _, m := range r.Mismatches {
+		d.mismatches[m.Path] = true
+		for p := m.Path; p != ""; {
+			p = p[:strings.LastIndex(p, "/")]
+			d.ancestors[p] = true
+		}
+	}
+	hunks := d.diff("", "", r.doc, true, r.spec, true, 0, false)
+
+	switch opts.Style {
+	case DiffSideBySide:
+		renderSideBySide(sb, hunks, color)
+	default:
+		renderUnified(sb, hunks, color)
+	}
+}
+
+// diffHunk is a group of lines which are either equal in the pattern and
+// in the document, or differ.
+type diffHunk struct {
+	equal    bool
+	expected []string
+	actual   []string
+}
+
+func equalHunk(line string) diffHunk {
+	return diffHunk{equal: true, expected: []string{line}, actual: []string{line}}
+}
+
+type differ struct {
+	defs           map[string]interface{} // the named patterns `#use` can refer to
+	mismatches     map[string]bool        // paths with a mismatch
+	ancestors      map[string]bool        // paths with a mismatch in a descendant
+	maxMatchingRun int
+}
+
+// diff renders the value at `path`, `prefix` being the `"key": ` part
+// for object members.
+func (d *differ) diff(path string, prefix string, x interface{}, xPresent bool, spec interface{},
+	specPresent bool, indent int, comma bool,
+) []diffHunk {
+	if resolved, err := resolveUse(spec, d.defs); err == nil && d.ancestors[path] && !d.mismatches[path] {
+		// descend into the referenced pattern to show the mismatches within
+		spec = resolved
+	}
+	pad := strings.Repeat("  ", indent)
+	suffix := ""
+	if comma {
+		suffix = ","
+	}
+
+	if !d.mismatches[path] && !d.ancestors[path] {
+		return []diffHunk{equalHunk(pad + prefix + collapsedJSON(x) + suffix)}
+	}
+
+	if !d.mismatches[path] && xPresent && specPresent {
+		xMap, xIsMap := x.(map[string]interface{})
+		specMap, specIsMap := spec.(map[string]interface{})
+		if xIsMap && specIsMap {
+			return d.diffContainer(pad+prefix+"{", d.diffMap(path, xMap, specMap, indent+1), pad+"}"+suffix,
+				indent+1)
+		}
+		xSlice, xIsSlice := x.([]interface{})
+		specSlice, specIsSlice := spec.([]interface{})
+		if xIsSlice && specIsSlice {
+			return d.diffContainer(pad+prefix+"[", d.diffSlice(path, xSlice, specSlice, indent+1), pad+"]"+suffix,
+				indent+1)
+		}
+	}
+
+	hunk := diffHunk{}
+	if specPresent {
+		hunk.expected = prettyJSON(spec, pad, prefix, suffix)
+	}
+	if xPresent {
+		hunk.actual = prettyJSON(x, pad, prefix, suffix)
+	}
+	return []diffHunk{hunk}
+}
+
+func (d *differ) diffContainer(open string, children [][]diffHunk, closing string, indent int) []diffHunk {
+	hunks := []diffHunk{equalHunk(open)}
+	hunks = append(hunks, d.collapseMatchingRuns(children, strings.Repeat("  ", indent))...)
+	return append(hunks, equalHunk(closing))
+}
+
+// collapseMatchingRuns joins the hunks of the entries of a container,
+// replacing long runs of matching entries with a single line.
+func (d *differ) collapseMatchingRuns(children [][]diffHunk, pad string) []diffHunk {
+	var hunks []diffHunk
+	var run [][]diffHunk
+	flush := func() {
+		if len(run) > d.maxMatchingRun {
+			hunks = append(hunks, equalHunk(fmt.Sprintf("%s… %d matching entries", pad, len(run))))
+		} else {
+			for _, child := range run {
+				hunks = append(hunks, child...)
+			}
+		}
+		run = nil
+	}
+	for _, child := range children {
+		if len(child) == 1 && child[0].equal {
+			run = append(run, child)
+			continue
+		}
+		flush()
+		hunks = append(hunks, child...)
+	}
+	flush()
+	return hunks
+}
+
+func (d *differ) diffMap(path string, xMap map[string]interface{}, specMap map[string]interface{},
+	indent int,
+) [][]diffHunk {
+	// the escaped literal keys of the pattern refer to the unescaped keys of the document
+	unescaped := make(map[string]interface{}, len(specMap))
+	for key, value := range specMap {
+		unescaped[unescapeKey(key)] = value
+	}
+	specMap = unescaped
+
+	var keys []string
+	for key := range xMap {
+		keys = append(keys, key)
+	}
+	for key := range specMap {
+		if _, ok := xMap[key]; !ok && d.mismatches[path+"/"+escapePointerToken(key)] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	children := make([][]diffHunk, 0, len(keys))
+	for i, key := range keys {
+		x, xPresent := xMap[key]
+		spec, specPresent := specMap[key]
+		if !specPresent {
+			// not constrained by the pattern, so it matches
+			spec, specPresent = x, true
+		}
+		children = append(children, d.diff(path+"/"+escapePointerToken(key), compactJSON(key)+": ",
+			x, xPresent, spec, specPresent, indent, i < len(keys)-1))
+	}
+	return children
+}
+
+func (d *differ) diffSlice(path string, xSlice []interface{}, specSlice []interface{}, indent int) [][]diffHunk {
+	//nolint:gomnd // the "magic" literal constant 2 here is clearer than a synthetic constant symbol
+	isArrayOf := len(specSlice) == 2 && isMarker(specSlice[0], "#array-of")
+
+	children := make([][]diffHunk, 0, len(xSlice))
+	for i, x := range xSlice {
+		var spec interface{}
+		switch {
+		case isArrayOf:
+			spec = specSlice[1]
+		case i < len(specSlice):
+			spec = specSlice[i]
+		}
+		children = append(children, d.diff(path+"/"+strconv.Itoa(i), "", x, true, spec, true, indent,
+			i < len(xSlice)-1))
+	}
+	return children
+}
+
+// collapsedJSON renders `x` on a single line, replacing the contents of
+// non-empty objects and arrays with an ellipsis.
+func collapsedJSON(x interface{}) string {
+	switch v := x.(type) {
+	case map[string]interface{}:
+		if len(v) > 0 {
+			return "{…}"
+		}
+	case []interface{}:
+		if len(v) > 0 {
+			return "[…]"
+		}
+	}
+	return compactJSON(x)
+}
+
+func compactJSON(x interface{}) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(x); err != nil {
+		return fmt.Sprintf("%v", x)
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// prettyJSON renders `x` indented on multiple lines, prepending `prefix` to
+// the first line and appending `suffix` to the last one.
+func prettyJSON(x interface{}, pad string, prefix string, suffix string) []string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent(pad, "  ")
+	if err := enc.Encode(x); err != nil {
+		return []string{pad + prefix + fmt.Sprintf("%v", x) + suffix}
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	lines[0] = pad + prefix + lines[0]
+	lines[len(lines)-1] += suffix
+	return lines
+}
+
+func renderUnified(sb *strings.Builder, hunks []diffHunk, color bool) {
+	writeLine := func(prefix string, line string, ansi string) {
+		if color && ansi != "" {
+			sb.WriteString(ansi + prefix + line + ansiReset + "\n")
+			return
+		}
+		sb.WriteString(prefix + line + "\n")
+	}
+
+	writeLine("--- ", "pattern", ansiRed)
+	writeLine("+++ ", "document", ansiGreen)
+	for _, h := range hunks {
+		if h.equal {
+			writeLine("  ", h.expected[0], "")
+			continue
+		}
+		for _, line := range h.expected {
+			writeLine("- ", line, ansiRed)
+		}
+		for _, line := range h.actual {
+			writeLine("+ ", line, ansiGreen)
+		}
+	}
+}
+
+func renderSideBySide(sb *strings.Builder, hunks []diffHunk, color bool) {
+	width := utf8.RuneCountInString("pattern")
+	for _, h := range hunks {
+		for _, line := range h.expected {
+			if n := utf8.RuneCountInString(line); n > width {
+				width = n
+			}
+		}
+	}
+
+	writeRow := func(left string, sep string, right string, changed bool) {
+		left += strings.Repeat(" ", width-utf8.RuneCountInString(left))
+		if color && changed {
+			left, right = ansiRed+left+ansiReset, ansiGreen+right+ansiReset
+		}
+		sb.WriteString(strings.TrimRight(left+sep+right, " ") + "\n")
+	}
+
+	writeRow("pattern", " | ", "document", false)
+	writeRow(strings.Repeat("-", width), "-+-", strings.Repeat("-", width), false)
+	for _, h := range hunks {
+		if h.equal {
+			writeRow(h.expected[0], " | ", h.actual[0], false)
+			continue
+		}
+		rows := len(h.expected)
+		if len(h.actual) > rows {
+			rows = len(h.actual)
+		}
+		for i := 0; i < rows; i++ {
+			var left, right string
+			sep := " ! "
+			switch {
+			case i >= len(h.expected):
+				right, sep = h.actual[i], " > "
+			case i >= len(h.actual):
+				left, sep = h.expected[i], " < "
+			default:
+				left, right = h.expected[i], h.actual[i]
+			}
+			writeRow(left, sep, right, true)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package matcher_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	matcher "github.com/panta/go-json-matcher"
+)
+
+const (
+	diffTestDocument = `{
+  "id": "42",
+  "title": "A short article.",
+  "publish": true,
+  "section_id": 42,
+  "type": "articles",
+  "tags": [ "society", 5, "history" ],
+  "author": { "id": 1, "name": "joe" },
+  "error": "boom",
+  "kind": "news",
+  "lang": "en"
+}`
+	diffTestPattern = `{
+  "id": "#uuid",
+  "title": "#string",
+  "publish": "#boolean",
+  "section_id": "#number",
+  "type": "articles",
+  "tags": [ "#array-of", "#string" ],
+  "author": { "id": "#number", "name": "#string" },
+  "created": "#datetime",
+  "error": "#notpresent"
+}`
+)
+
+func TestReportDiff(t *testing.T) {
+	report, err := matcher.MustCompile(diffTestPattern).Match([]byte(diffTestDocument))
+	if err != nil {
+		t.Fatalf("Match() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		opts matcher.DiffOptions
+		want string
+	}{
+		{name: "unified", opts: matcher.DiffOptions{}, want: `--- pattern
++++ document
+  {
+    "author": {…},
+-   "created": "#datetime",
+-   "error": "#notpresent",
++   "error": "boom",
+-   "id": "#uuid",
++   "id": "42",
+    … 4 matching entries
+    "tags": [
+      "society",
+-     "#string",
++     5,
+      "history"
+    ],
+    "title": "A short article.",
+    "type": "articles"
+  }
+`},
+		{name: "unified-long-runs", opts: matcher.DiffOptions{MaxMatchingRun: 10}, want: `--- pattern
++++ document
+  {
+    "author": {…},
+-   "created": "#datetime",
+-   "error": "#notpresent",
++   "error": "boom",
+-   "id": "#uuid",
++   "id": "42",
+    "kind": "news",
+    "lang": "en",
+    "publish": true,
+    "section_id": 42,
+    "tags": [
+      "society",
+-     "#string",
++     5,
+      "history"
+    ],
+    "title": "A short article.",
+    "type": "articles"
+  }
+`},
+		{name: "side-by-side", opts: matcher.DiffOptions{Style: matcher.DiffSideBySide},
+			want: `pattern                        | document
+-------------------------------+-------------------------------
+{                              | {
+  "author": {…},               |   "author": {…},
+  "created": "#datetime",      <
+  "error": "#notpresent",      !   "error": "boom",
+  "id": "#uuid",               !   "id": "42",
+  … 4 matching entries         |   … 4 matching entries
+  "tags": [                    |   "tags": [
+    "society",                 |     "society",
+    "#string",                 !     5,
+    "history"                  |     "history"
+  ],                           |   ],
+  "title": "A short article.", |   "title": "A short article.",
+  "type": "articles"           |   "type": "articles"
+}                              | }
+`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := report.Diff(tt.opts); got != tt.want {
+				t.Errorf("Diff() got =\n%s\nwant =\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReportDiffContainerMismatch(t *testing.T) {
+	report, err := matcher.MustCompile(`{ "ids": [ 1, 2 ] }`).Match([]byte(`{ "ids": [ 1, 2, 3 ] }`))
+	if err != nil {
+		t.Fatalf("Match() error = %v", err)
+	}
+	want := `--- pattern
++++ document
+  {
+-   "ids": [
+-     1,
+-     2
+-   ]
++   "ids": [
++     1,
++     2,
++     3
++   ]
+  }
+`
+	if got := report.Diff(matcher.DiffOptions{}); got != want {
+		t.Errorf("Diff() got =\n%s\nwant =\n%s", got, want)
+	}
+}
+
+func TestReportDiffColor(t *testing.T) {
+	report, err := matcher.MustCompile(`{ "id": "#uuid" }`).Match([]byte(`{ "id": 42 }`))
+	if err != nil {
+		t.Fatalf("Match() error = %v", err)
+	}
+
+	colored := report.Diff(matcher.DiffOptions{Color: matcher.ColorAlways})
+	if !strings.Contains(colored, "\x1b[31m-   \"id\": \"#uuid\"\x1b[0m") ||
+		!strings.Contains(colored, "\x1b[32m+   \"id\": 42\x1b[0m") {
+		t.Errorf("Diff() expected ANSI colours, got %q", colored)
+	}
+
+	var buf bytes.Buffer
+	err = report.WriteDiff(&buf, matcher.DiffOptions{})
+	if err != nil {
+		t.Fatalf("WriteDiff() error = %v", err)
+	}
+	if strings.Contains(buf.String(), "\x1b[") {
+		t.Errorf("WriteDiff() expected no colours when not writing to a terminal, got %q", buf.String())
+	}
+	if buf.String() != report.Diff(matcher.DiffOptions{Color: matcher.ColorNever}) {
+		t.Errorf("WriteDiff() got = %q, want the same as Diff()", buf.String())
+	}
+}
@@ -0,0 +1,116 @@
+package matcher
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// The sentinel errors wrapped by the errors of this package, which can be
+// checked with errors.Is.
+var (
+	// ErrInvalidDocument reports a document which can't be decoded.
+	ErrInvalidDocument = errors.New("invalid document")
+	// ErrInvalidPattern reports a pattern which can't be decoded or is
+	// malformed (see PatternError), e.g. because of a reference to an
+	// undefined named pattern.
+	ErrInvalidPattern = errors.New("invalid pattern")
+	// ErrUnknownMarker reports a marker which doesn't exist, e.g. a
+	// misspelled `#strnig`. Compile reports it within a PatternError, so it
+	// matches ErrInvalidPattern as well.
+	ErrUnknownMarker = errors.New("unknown marker")
+)
+
+// PatternError reports a malformed pattern, e.g. an unknown marker, an
+// invalid regular expression in `#regex` or an `#array-of` pattern with the
+// wrong number of elements. Compile and its variants check the whole pattern
+// up front, so these errors are returned before matching any document.
+type PatternError struct {
+	// Pointer is the JSON Pointer of the malformed value within the pattern.
+	Pointer string
+	// Line and Column locate the malformed value in the source of the
+	// pattern (both 1-based), when it was compiled from text; they are zero
+	// when the position is unknown.
+	Line   int
+	Column int
+	// Err describes the problem.
+	Err error
+}
+
+func (e *PatternError) Error() string {
+	pointer := e.Pointer
+	if pointer == "" {
+		pointer = "/"
+	}
+	if e.Line > 0 {
+		return fmt.Sprintf("invalid pattern at %s (line %d, column %d): %v", pointer, e.Line, e.Column, e.Err)
+	}
+	return fmt.Sprintf("invalid pattern at %s: %v", pointer, e.Err)
+}
+
+// Unwrap returns the error describing the problem.
+func (e *PatternError) Unwrap() error {
+	return e.Err
+}
+
+// Is makes a PatternError match ErrInvalidPattern.
+func (e *PatternError) Is(target error) bool {
+	return target == ErrInvalidPattern
+}
+
+// MismatchError is an error reporting that a document doesn't match a
+// pattern, for APIs which prefer an error to a boolean result (see
+// Report.Err and Pattern.Check).
+type MismatchError struct {
+	// Report lists all the mismatches found.
+	Report *Report
+}
+
+func (e *MismatchError) Error() string {
+	lines := make([]string, 0, len(e.Report.Mismatches))
+	for _, m := range e.Report.Mismatches {
+		lines = append(lines, m.String())
+	}
+	return "document doesn't match the pattern: " + strings.Join(lines, "; ")
+}
+
+// sentinelError adds a sentinel error to the chain of `err`, without
+// changing its message (Go 1.18 doesn't allow wrapping two errors).
+type sentinelError struct {
+	sentinel error
+	err      error
+}
+
+// withSentinel returns `err` wrapped so that errors.Is(err, sentinel) is true.
+func withSentinel(sentinel error, err error) error {
+	if err == nil || errors.Is(err, sentinel) {
+		return err
+	}
+	return &sentinelError{sentinel: sentinel, err: err}
+}
+
+func (e *sentinelError) Error() string {
+	return e.err.Error()
+}
+
+func (e *sentinelError) Unwrap() error {
+	return e.err
+}
+
+func (e *sentinelError) Is(target error) bool {
+	return target == e.sentinel
+}
+
+// locatePatternError fills in the position of a *PatternError in `err`, using
+// the positions of the pattern values returned by `positions`, which is only
+// called when needed.
+func locatePatternError(err error, positions func() map[string]sourcePosition) error {
+	var patternErr *PatternError
+	if !errors.As(err, &patternErr) || patternErr.Line > 0 {
+		return err
+	}
+	if pos, ok := lookupPosition(positions(), patternErr.Pointer); ok {
+		patternErr.Line, patternErr.Column = pos.line, pos.column
+	}
+	return err
+}
@@ -0,0 +1,201 @@
+package matcher_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	matcher "github.com/panta/go-json-matcher"
+)
+
+func TestPatternError(t *testing.T) {
+	compileJSON := func(s string) error {
+		_, err := matcher.Compile([]byte(s))
+		return err
+	}
+	compileYAML := func(s string) error {
+		_, err := matcher.CompileYAML([]byte(s))
+		return err
+	}
+	compileRelaxed := func(s string) error {
+		_, err := matcher.CompileRelaxed([]byte(s))
+		return err
+	}
+
+	tests := []struct {
+		name        string
+		compile     func(s string) error
+		jSpec       string
+		wantPointer string
+		wantLine    int
+		wantColumn  int
+		wantErr     string
+	}{
+		{name: "regex", compile: compileJSON, jSpec: "{\n  \"id\": \"#uuid\",\n  \"name\": \"#regex *+\"\n}",
+			wantPointer: "/name", wantLine: 3, wantColumn: 11, wantErr: `invalid regex argument to #regex`},
+		{name: "unknown-marker", compile: compileJSON, jSpec: `{ "tags": [ "#string", "#strnig" ] }`,
+			wantPointer: "/tags/1", wantLine: 1, wantColumn: 24, wantErr: `unsupported pattern '#strnig'`},
+		{name: "array-of-arity", compile: compileJSON, jSpec: `{ "a": { "b": [ "#array-of", "#number", 1 ] } }`,
+			wantPointer: "/a/b", wantLine: 1, wantColumn: 15, wantErr: `#array-of expects exactly one pattern, got 2`},
+		{name: "array-of-item", compile: compileJSON, jSpec: `[ "#array-of", { "n": "#len 3..1" } ]`,
+			wantPointer: "/1/n", wantLine: 1, wantColumn: 23, wantErr: `invalid #len marker`},
+		{name: "combinator", compile: compileJSON, jSpec: `{ "x": [ "#json" ] }`,
+			wantPointer: "/x", wantLine: 1, wantColumn: 8, wantErr: `invalid #json pattern: expected exactly one argument`},
+		{name: "combinator-argument", compile: compileJSON, jSpec: `{ "x": [ "#json", { "y": "#bogus" } ] }`,
+			wantPointer: "/x/1/y", wantLine: 1, wantColumn: 26, wantErr: `unsupported pattern '#bogus'`},
+		{name: "key-pattern", compile: compileJSON, jSpec: `{ "#key #regex (": "#string" }`,
+			wantPointer: "/#key #regex (", wantLine: 1, wantColumn: 20, wantErr: `invalid regex argument to #regex`},
+		{name: "where", compile: compileJSON, jSpec: `{ "#where": [ "a < b", "a <" ] }`,
+			wantPointer: "/#where/1", wantLine: 1, wantColumn: 24, wantErr: `invalid #where expression "a <"`},
+		{name: "properties", compile: compileJSON, jSpec: `{ "#properties": "many" }`,
+			wantPointer: "/#properties", wantLine: 1, wantColumn: 18, wantErr: `invalid #properties range`},
+		{name: "definition", compile: compileJSON, jSpec: `{ "#defs": { "user": { "id": "#uid" } }, "u": "#use user" }`,
+			wantPointer: "/#defs/user/id", wantLine: 1, wantColumn: 30, wantErr: `unsupported pattern '#uid'`},
+		{name: "escaped-pointer", compile: compileJSON, jSpec: `{ "a/b": { "c~d": "#nope" } }`,
+			wantPointer: "/a~1b/c~0d", wantLine: 1, wantColumn: 19, wantErr: `unsupported pattern '#nope'`},
+		{name: "root", compile: compileJSON, jSpec: `"#nope"`,
+			wantPointer: "", wantLine: 1, wantColumn: 1, wantErr: `invalid pattern at / (line 1, column 1)`},
+		{name: "yaml", compile: compileYAML, jSpec: "id: \"#uuid\"\ntags:\n  - \"#datetime-within soon\"\n",
+			wantPointer: "/tags/0", wantLine: 3, wantColumn: 5, wantErr: `invalid #datetime-within marker`},
+		{name: "relaxed", compile: compileRelaxed, jSpec: "{\n  // the id\n  id: '#uuid v9',\n}",
+			wantPointer: "/id", wantLine: 3, wantColumn: 7, wantErr: `invalid #uuid marker`},
+		{name: "enum", compile: compileRelaxed, jSpec: "{ kind: ['#enum', [1]] }",
+			wantPointer: "/kind", wantLine: 1, wantColumn: 9, wantErr: `invalid #enum pattern: values must be literals`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.compile(tt.jSpec)
+			var patternErr *matcher.PatternError
+			if !errors.As(err, &patternErr) {
+				t.Fatalf("Compile() error = %v, want a *PatternError", err)
+			}
+			if patternErr.Pointer != tt.wantPointer || patternErr.Line != tt.wantLine ||
+				patternErr.Column != tt.wantColumn {
+				t.Errorf("Compile() error at %q (line %d, column %d), want %q (line %d, column %d)",
+					patternErr.Pointer, patternErr.Line, patternErr.Column, tt.wantPointer, tt.wantLine, tt.wantColumn)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Compile() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPatternErrorMessages(t *testing.T) {
+	lib, err := matcher.LoadPatternLibrary(fstest.MapFS{
+		"user.yaml": {Data: []byte("id: \"#uuid\"\nemail: \"#emial\"\n")},
+	})
+	if err == nil {
+		t.Fatalf("LoadPatternLibrary() = %v, want an error", lib)
+	}
+	var patternErr *matcher.PatternError
+	if !errors.As(err, &patternErr) || patternErr.Pointer != "/email" || patternErr.Line != 2 {
+		t.Errorf("LoadPatternLibrary() error = %#v, want a *PatternError at /email, line 2", err)
+	}
+	want := `user.yaml: invalid pattern at /email (line 2, column 8): unsupported pattern '#emial'`
+	if err.Error() != want {
+		t.Errorf("LoadPatternLibrary() error = %q, want %q", err, want)
+	}
+
+	err = (&matcher.PatternError{Pointer: "/a", Err: errors.New("boom")})
+	if want = `invalid pattern at /a: boom`; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err, want)
+	}
+}
+
+func TestSentinelErrors(t *testing.T) {
+	pattern := matcher.MustCompile(`{ "id": "#uuid" }`)
+
+	tests := []struct {
+		name string
+		err  func() error
+		want []error
+		not  []error
+	}{
+		{name: "invalid-document", err: func() error {
+			_, err := pattern.Match([]byte(`{ "id": `))
+			return err
+		}, want: []error{matcher.ErrInvalidDocument}, not: []error{matcher.ErrInvalidPattern}},
+		{name: "invalid-yaml-document", err: func() error {
+			_, err := pattern.MatchYAML([]byte("id: [1"))
+			return err
+		}, want: []error{matcher.ErrInvalidDocument}},
+		{name: "invalid-cbor-document", err: func() error {
+			_, err := pattern.MatchCBOR([]byte{0xff})
+			return err
+		}, want: []error{matcher.ErrInvalidDocument}},
+		{name: "document-strings", err: func() error {
+			_, err := matcher.JSONStringMatches(`{`, `"#ignore"`)
+			return err
+		}, want: []error{matcher.ErrInvalidDocument}},
+		{name: "invalid-pattern-syntax", err: func() error {
+			_, err := matcher.Compile([]byte(`{ "id": `))
+			return err
+		}, want: []error{matcher.ErrInvalidPattern}, not: []error{matcher.ErrInvalidDocument, matcher.ErrUnknownMarker}},
+		{name: "invalid-relaxed-pattern-syntax", err: func() error {
+			_, err := matcher.CompileRelaxed([]byte(`{ id: `))
+			return err
+		}, want: []error{matcher.ErrInvalidPattern}},
+		{name: "unknown-marker", err: func() error {
+			_, err := matcher.JSONStringMatches(`{}`, `{ "id": "#uid" }`)
+			return err
+		}, want: []error{matcher.ErrInvalidPattern, matcher.ErrUnknownMarker}, not: []error{matcher.ErrInvalidDocument}},
+		{name: "invalid-marker-argument", err: func() error {
+			_, err := matcher.Compile([]byte(`"#regex ("`))
+			return err
+		}, want: []error{matcher.ErrInvalidPattern}, not: []error{matcher.ErrUnknownMarker}},
+		{name: "undefined-pattern", err: func() error {
+			_, err := matcher.Compile([]byte(`"#use user"`))
+			return err
+		}, want: []error{matcher.ErrInvalidPattern}},
+		{name: "unbound-parameter", err: func() error {
+			_, err := matcher.MustCompile(`"#param name"`).Match([]byte(`"joe"`))
+			return err
+		}, want: []error{matcher.ErrInvalidPattern}},
+		{name: "invalid-pattern-file", err: func() error {
+			_, err := matcher.LoadPatternLibrary(fstest.MapFS{"a.json": {Data: []byte(`"#include b.json"`)}})
+			return err
+		}, want: []error{matcher.ErrInvalidPattern}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.err()
+			for _, target := range tt.want {
+				if !errors.Is(err, target) {
+					t.Errorf("errors.Is(%v, %v) = false, want true", err, target)
+				}
+			}
+			for _, target := range tt.not {
+				if errors.Is(err, target) {
+					t.Errorf("errors.Is(%v, %v) = true, want false", err, target)
+				}
+			}
+		})
+	}
+}
+
+func TestMismatchError(t *testing.T) {
+	pattern := matcher.MustCompile(`{ "id": "#uuid", "tags": [ "#array-of", "#string" ] }`)
+
+	if err := pattern.Check([]byte(`{ "id": "5f0c3b9e-1a2b-4c3d-8e4f-5a6b7c8d9e0f", "tags": [] }`)); err != nil {
+		t.Errorf("Check() error = %v, want nil", err)
+	}
+
+	err := pattern.Check([]byte(`{ "id": "42", "tags": [ "a", 5 ] }`))
+	var mismatchErr *matcher.MismatchError
+	if !errors.As(err, &mismatchErr) {
+		t.Fatalf("Check() error = %v, want a *MismatchError", err)
+	}
+	if len(mismatchErr.Report.Mismatches) != 2 {
+		t.Errorf("Check() report = %v, want 2 mismatches", mismatchErr.Report)
+	}
+	want := `document doesn't match the pattern: /id: "42" doesn't match "#uuid"; /tags/1: 5 doesn't match "#string"`
+	if err.Error() != want {
+		t.Errorf("Check() error = %q, want %q", err, want)
+	}
+
+	if err := pattern.Check([]byte(`{`)); !errors.Is(err, matcher.ErrInvalidDocument) {
+		t.Errorf("Check() error = %v, want ErrInvalidDocument", err)
+	}
+}
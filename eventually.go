@@ -0,0 +1,77 @@
+package matcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// defaultEventuallyInterval is the interval used by Eventually when the
+// given one isn't positive.
+const defaultEventuallyInterval = 100 * time.Millisecond
+
+// Eventually repeatedly calls `fetch` every `interval` and matches the
+// returned JSON document against `pattern`, until the document matches or
+// `ctx` is done. A non-positive `interval` stands for the default of 100ms.
+// Errors returned by `fetch` and documents which aren't valid JSON are
+// considered transient and cause another attempt; an error in the pattern
+// itself is returned immediately.
+// When `ctx` is done before a match, the report of the last attempt is
+// returned (nil if no document could be fetched) together with an error
+// wrapping the context error.
+func Eventually(ctx context.Context, fetch func() ([]byte, error), pattern *Pattern,
+	interval time.Duration,
+) (*Report, error) {
+	if interval <= 0 {
+		interval = defaultEventuallyInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var lastReport *Report
+	var lastErr error
+	for {
+		report, transient, err := eventuallyAttempt(fetch, pattern)
+		switch {
+		case err == nil && report.Matches():
+			return report, nil
+		case err == nil:
+			lastReport, lastErr = report, nil
+		case transient:
+			lastErr = err
+		default:
+			// a malformed pattern won't get any better by retrying
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			if lastErr != nil {
+				return lastReport, fmt.Errorf("document didn't match before the context was done (last error: %v): %w",
+					lastErr, ctx.Err())
+			}
+			return lastReport, fmt.Errorf("document didn't match before the context was done: %w", ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// eventuallyAttempt fetches and matches a document once.
+// The boolean result tells whether a returned error is transient.
+func eventuallyAttempt(fetch func() ([]byte, error), pattern *Pattern) (*Report, bool, error) {
+	j, err := fetch()
+	if err != nil {
+		return nil, true, fmt.Errorf("can't fetch document: %w", err)
+	}
+	var jAny interface{}
+	err = json.Unmarshal(j, &jAny)
+	if err != nil {
+		return nil, true, withSentinel(ErrInvalidDocument, fmt.Errorf("can't unmarshal left argument: %w", err))
+	}
+	report, err := pattern.matchValue(jAny)
+	if err != nil {
+		return nil, false, err
+	}
+	return report, false, nil
+}
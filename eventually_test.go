@@ -0,0 +1,111 @@
+package matcher_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	matcher "github.com/panta/go-json-matcher"
+)
+
+func TestEventually(t *testing.T) {
+	pattern := matcher.MustCompile(`{ "status": "completed", "id": "#number" }`)
+
+	tests := []struct {
+		name        string
+		responses   []string
+		fetchErrors int
+		pattern     *matcher.Pattern
+		wantErr     bool
+		wantReport  bool
+		wantMatches bool
+	}{
+		{name: "immediate", responses: []string{
+			`{ "status": "completed", "id": 1 }`,
+		}, pattern: pattern, wantReport: true, wantMatches: true},
+		{name: "after-some-attempts", responses: []string{
+			`{ "status": "pending", "id": 1 }`,
+			`not yet JSON`,
+			`{ "status": "running", "id": 1 }`,
+			`{ "status": "completed", "id": 1 }`,
+		}, pattern: pattern, wantReport: true, wantMatches: true},
+		{name: "after-fetch-errors", responses: []string{
+			`{ "status": "completed", "id": 1 }`,
+		}, fetchErrors: 2, pattern: pattern, wantReport: true, wantMatches: true},
+		{name: "timeout", responses: []string{
+			`{ "status": "pending", "id": 1 }`,
+		}, pattern: pattern, wantErr: true, wantReport: true},
+		{name: "timeout-without-document", fetchErrors: 1000, pattern: pattern, wantErr: true},
+		{name: "bad-pattern", responses: []string{
+			`{ "status": "pending", "id": 1 }`,
+		}, pattern: matcher.MustCompile(`{ "status": "#param status" }`), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+
+			calls := 0
+			fetch := func() ([]byte, error) {
+				calls++
+				if calls <= tt.fetchErrors {
+					return nil, errors.New("connection refused")
+				}
+				i := calls - tt.fetchErrors - 1
+				if i >= len(tt.responses) {
+					i = len(tt.responses) - 1
+				}
+				return []byte(tt.responses[i]), nil
+			}
+
+			got, err := matcher.Eventually(ctx, fetch, tt.pattern, time.Millisecond)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Eventually() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if (got != nil) != tt.wantReport {
+				t.Errorf("Eventually() report = %v, wantReport %v", got, tt.wantReport)
+				return
+			}
+			if got != nil && got.Matches() != tt.wantMatches {
+				t.Errorf("Eventually() matches = %v, want %v (%v)", got.Matches(), tt.wantMatches, got)
+			}
+		})
+	}
+}
+
+func TestEventuallyTimeoutError(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	fetch := func() ([]byte, error) { return []byte(`{ "status": "pending" }`), nil }
+	report, err := matcher.Eventually(ctx, fetch, matcher.MustCompile(`{ "status": "completed" }`), time.Millisecond)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Eventually() error = %v, want context.DeadlineExceeded", err)
+	}
+	want := `/status: expected "completed", got "pending"`
+	if report == nil || report.String() != want {
+		t.Errorf("Eventually() report = %v, want %q", report, want)
+	}
+}
+
+func TestEventuallyNonPositiveInterval(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	for _, interval := range []time.Duration{0, -time.Second} {
+		attempts := 0
+		fetch := func() ([]byte, error) {
+			attempts++
+			if attempts < 2 {
+				return []byte(`{ "status": "pending" }`), nil
+			}
+			return []byte(`{ "status": "completed" }`), nil
+		}
+		report, err := matcher.Eventually(ctx, fetch, matcher.MustCompile(`{ "status": "completed" }`), interval)
+		if err != nil || !report.Matches() {
+			t.Errorf("Eventually(%v) = %v, %v, want a match", interval, report, err)
+		}
+	}
+}
@@ -0,0 +1,487 @@
+package matcher
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// FormatOptions customises the layout produced by FormatWithOptions.
+type FormatOptions struct {
+	// SortKeys sorts the keys of objects (comments move with their keys).
+	SortKeys bool
+}
+
+// markerAliases maps the alternative spellings of markers to the canonical
+// ones used by Format.
+//
+//nolint:gochecknoglobals // an internal global here is clearer than a function returning a constant map
+var markerAliases = map[string]string{
+	"#bool": "#boolean",
+}
+
+const (
+	formatIndent = "  "
+	// formatInlineWidth is the maximum length of an array of literals and
+	// markers printed on a single line.
+	formatInlineWidth = 80
+)
+
+// Format returns the pattern specifier in `pattern`, written in JSON or in
+// the relaxed dialect accepted by CompileRelaxed, in a canonical layout:
+// objects have a key per line, arrays of literals and markers are printed on
+// a single line when short, indentation is two spaces and markers use their
+// canonical spelling (e.g. `#boolean` rather than `#bool`).
+// Comments and single blank lines between keys or items are preserved, and
+// the output is plain JSON unless the input has comments or numbers only the
+// relaxed dialect accepts.
+func Format(pattern []byte) ([]byte, error) {
+	return FormatWithOptions(pattern, FormatOptions{})
+}
+
+// FormatWithOptions is like Format, with a layout customised by `opts`.
+func FormatWithOptions(pattern []byte, opts FormatOptions) ([]byte, error) {
+	p := formatParser{relaxedParser: relaxedParser{src: string(pattern), line: 1, column: 1,
+		positions: map[string]sourcePosition{}}}
+	root, err := p.parseDocument()
+	if err != nil {
+		return nil, withSentinel(ErrInvalidPattern, err)
+	}
+	pr := formatPrinter{opts: opts}
+	pr.comments(root.comments, 0)
+	pr.value(root.value, 0, false)
+	pr.buf.WriteByte('\n')
+	pr.comments(root.trailing, 0)
+	return pr.buf.Bytes(), nil
+}
+
+// formatComment is a comment in the source of a pattern.
+type formatComment struct {
+	text        string
+	line        int  // the line where the comment starts
+	blankBefore bool // whether a blank line precedes the comment
+}
+
+// formatNode is a value in the concrete syntax tree of a pattern.
+type formatNode struct {
+	// the source text of a number, boolean or null
+	literal *string
+	// the value of a string
+	str      *string
+	isObject bool
+	members  []formatMember
+	trailing []formatComment // the comments before the closing bracket
+}
+
+// formatMember is an item of an array or a key/value pair of an object.
+type formatMember struct {
+	comments    []formatComment // the comments preceding the member
+	blankBefore bool
+	key         string // the key of object members
+	value       *formatNode
+	lineComment string // a comment following the member on the same line
+}
+
+type formatDocument struct {
+	comments []formatComment
+	value    *formatNode
+	trailing []formatComment
+}
+
+// formatParser parses a pattern into a concrete syntax tree, keeping the
+// comments the relaxedParser skips.
+type formatParser struct {
+	relaxedParser
+	pending          []formatComment // the comments read and not yet attached to a node
+	blankBeforeToken bool            // whether a blank line precedes the next token
+}
+
+// skip skips white space, collecting the comments.
+func (p *formatParser) skip() error {
+	newlines := 0
+	p.blankBeforeToken = false
+	for !p.eof() {
+		r := p.peek()
+		switch {
+		case r == '\n':
+			newlines++
+			p.next()
+		case unicode.IsSpace(r) || r == '\ufeff':
+			p.next()
+		case strings.HasPrefix(p.src[p.pos:], "//") || strings.HasPrefix(p.src[p.pos:], "/*"):
+			comment := formatComment{line: p.line, blankBefore: newlines > 1}
+			start := p.pos
+			if err := p.skipComment(); err != nil {
+				return err
+			}
+			comment.text = strings.TrimRightFunc(p.src[start:p.pos], unicode.IsSpace)
+			p.pending = append(p.pending, comment)
+			newlines = 0
+		default:
+			p.blankBeforeToken = newlines > 1
+			return nil
+		}
+	}
+	return nil
+}
+
+// skipComment skips the comment starting at the current position.
+func (p *formatParser) skipComment() error {
+	if strings.HasPrefix(p.src[p.pos:], "//") {
+		for !p.eof() && p.peek() != '\n' {
+			p.next()
+		}
+		return nil
+	}
+	line, column := p.line, p.column
+	p.next()
+	p.next()
+	for !strings.HasPrefix(p.src[p.pos:], "*/") {
+		if p.eof() {
+			p.line, p.column = line, column
+			return p.errorf("unterminated comment")
+		}
+		p.next()
+	}
+	p.next()
+	p.next()
+	return nil
+}
+
+// takeComments returns the pending comments, clearing them.
+func (p *formatParser) takeComments() []formatComment {
+	comments := p.pending
+	p.pending = nil
+	return comments
+}
+
+// takeLineComment returns the pending comments starting on `line`, joined.
+func (p *formatParser) takeLineComment(line int) string {
+	var texts []string
+	rest := p.pending[:0]
+	for _, c := range p.pending {
+		if c.line == line && !strings.Contains(c.text, "\n") {
+			texts = append(texts, c.text)
+		} else {
+			rest = append(rest, c)
+		}
+	}
+	p.pending = rest
+	return strings.Join(texts, " ")
+}
+
+func (p *formatParser) parseDocument() (*formatDocument, error) {
+	if err := p.checkSyntax(); err != nil {
+		return nil, err
+	}
+	if err := p.skip(); err != nil {
+		return nil, err
+	}
+	doc := &formatDocument{comments: p.takeComments()}
+	value, err := p.parseNode()
+	if err != nil {
+		return nil, err
+	}
+	doc.value = value
+	if err := p.skip(); err != nil {
+		return nil, err
+	}
+	if !p.eof() {
+		return nil, p.errorf("unexpected %s after top-level value", p.describeNext())
+	}
+	doc.trailing = p.takeComments()
+	return doc, nil
+}
+
+// checkSyntax parses the whole pattern with the relaxedParser, which reports
+// all the syntax errors.
+func (p *formatParser) checkSyntax() error {
+	_, err := (&relaxedParser{src: p.src, line: 1, column: 1, positions: map[string]sourcePosition{}}).parse()
+	return err
+}
+
+func (p *formatParser) parseNode() (*formatNode, error) {
+	switch r := p.peek(); {
+	case r == '{' || r == '[':
+		return p.parseContainer(r == '{')
+	case r == '"' || r == '\'':
+		s, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		return &formatNode{str: &s}, nil
+	}
+	start := p.pos
+	if _, err := p.parseValue(""); err != nil {
+		return nil, err
+	}
+	text := p.src[start:p.pos]
+	return &formatNode{literal: &text}, nil
+}
+
+func (p *formatParser) parseContainer(isObject bool) (*formatNode, error) {
+	closing := ']'
+	if isObject {
+		closing = '}'
+	}
+	p.next() // [ or {
+	node := &formatNode{isObject: isObject, members: []formatMember{}}
+	for {
+		if err := p.skip(); err != nil {
+			return nil, err
+		}
+		if p.peek() == closing {
+			p.next()
+			node.trailing = p.takeComments()
+			return node, nil
+		}
+
+		m := formatMember{blankBefore: p.blankBeforeToken, comments: p.takeComments()}
+		if len(m.comments) > 0 {
+			m.blankBefore = m.comments[0].blankBefore
+		}
+		if isObject {
+			key, err := p.parseKey()
+			if err != nil {
+				return nil, err
+			}
+			m.key = key
+			if err := p.skip(); err != nil {
+				return nil, err
+			}
+			if p.peek() != ':' {
+				return nil, p.errorf("unexpected %s, expected ':'", p.describeNext())
+			}
+			p.next()
+			if err := p.skip(); err != nil {
+				return nil, err
+			}
+			// comments between the key and the value move before the key
+			m.comments = append(m.comments, p.takeComments()...)
+		}
+		value, err := p.parseNode()
+		if err != nil {
+			return nil, err
+		}
+		m.value = value
+		line := p.line
+
+		if err := p.skip(); err != nil {
+			return nil, err
+		}
+		switch p.peek() {
+		case ',':
+			p.next()
+			if err := p.skipSameLine(); err != nil {
+				return nil, err
+			}
+		case closing:
+		default:
+			return nil, p.errorf("unexpected %s, expected ',' or '%c'", p.describeNext(), closing)
+		}
+		m.lineComment = p.takeLineComment(line)
+		node.members = append(node.members, m)
+	}
+}
+
+// skipSameLine skips the white space and the comments on the current line.
+func (p *formatParser) skipSameLine() error {
+	for !p.eof() {
+		r := p.peek()
+		switch {
+		case r == '\n':
+			return nil
+		case unicode.IsSpace(r):
+			p.next()
+		case strings.HasPrefix(p.src[p.pos:], "//") || strings.HasPrefix(p.src[p.pos:], "/*"):
+			comment := formatComment{line: p.line}
+			start := p.pos
+			if err := p.skipComment(); err != nil {
+				return err
+			}
+			comment.text = strings.TrimRightFunc(p.src[start:p.pos], unicode.IsSpace)
+			p.pending = append(p.pending, comment)
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+func (p *formatParser) parseKey() (string, error) {
+	switch r := p.peek(); {
+	case r == '"' || r == '\'':
+		return p.parseString()
+	case isIdentifierStart(r):
+		return p.parseIdentifier(), nil
+	}
+	return "", p.errorf("unexpected %s, expected an object key", p.describeNext())
+}
+
+// formatPrinter prints a concrete syntax tree in the canonical layout.
+type formatPrinter struct {
+	buf  bytes.Buffer
+	opts FormatOptions
+}
+
+func (pr *formatPrinter) indent(depth int) {
+	pr.buf.WriteString(strings.Repeat(formatIndent, depth))
+}
+
+func (pr *formatPrinter) comments(comments []formatComment, depth int) {
+	for _, c := range comments {
+		pr.indent(depth)
+		pr.buf.WriteString(c.text)
+		pr.buf.WriteByte('\n')
+	}
+}
+
+// value prints `n` at the nesting level `depth`; `literals` is true for the
+// values of `#enum` patterns, where markers are literal strings.
+func (pr *formatPrinter) value(n *formatNode, depth int, literals bool) {
+	switch {
+	case n.str != nil:
+		s := *n.str
+		if !literals {
+			s = canonicalMarkerSpelling(s)
+		}
+		pr.buf.WriteString(quoteFormatString(s))
+	case n.literal != nil:
+		pr.buf.WriteString(*n.literal)
+	case n.isObject:
+		pr.object(n, depth)
+	default:
+		pr.array(n, depth)
+	}
+}
+
+func (pr *formatPrinter) object(n *formatNode, depth int) {
+	if len(n.members) == 0 && len(n.trailing) == 0 {
+		pr.buf.WriteString("{}")
+		return
+	}
+	members := n.members
+	if pr.opts.SortKeys {
+		members = append([]formatMember{}, members...)
+		sort.SliceStable(members, func(i, j int) bool { return members[i].key < members[j].key })
+	}
+	pr.buf.WriteString("{\n")
+	for i, m := range members {
+		pr.member(m, i, i == len(members)-1, depth+1, func() {
+			key := m.key
+			if isKeyPattern, keyPattern := getKeyPattern(key); isKeyPattern {
+				key = keyMarker + " " + canonicalMarkerSpelling(keyPattern)
+			}
+			pr.buf.WriteString(quoteFormatString(key))
+			pr.buf.WriteString(": ")
+			pr.value(m.value, depth+1, false)
+		})
+	}
+	pr.comments(n.trailing, depth+1)
+	pr.indent(depth)
+	pr.buf.WriteByte('}')
+}
+
+func (pr *formatPrinter) array(n *formatNode, depth int) {
+	literals := isFormatEnum(n)
+	if inline, ok := pr.inlineArray(n); ok {
+		pr.buf.WriteString(inline)
+		return
+	}
+	pr.buf.WriteString("[\n")
+	for i, m := range n.members {
+		pr.member(m, i, i == len(n.members)-1, depth+1, func() {
+			pr.value(m.value, depth+1, literals && i > 0)
+		})
+	}
+	pr.comments(n.trailing, depth+1)
+	pr.indent(depth)
+	pr.buf.WriteByte(']')
+}
+
+// member prints an item of an array or object, `print` printing the item
+// itself.
+func (pr *formatPrinter) member(m formatMember, i int, last bool, depth int, print func()) {
+	if m.blankBefore && i > 0 {
+		pr.buf.WriteByte('\n')
+	}
+	pr.comments(m.comments, depth)
+	pr.indent(depth)
+	print()
+	if !last {
+		pr.buf.WriteByte(',')
+	}
+	if m.lineComment != "" {
+		pr.buf.WriteByte(' ')
+		pr.buf.WriteString(m.lineComment)
+	}
+	pr.buf.WriteByte('\n')
+}
+
+// inlineArray returns `n` printed on a single line, if it is short and made
+// of literals and markers only, without comments.
+func (pr *formatPrinter) inlineArray(n *formatNode) (string, bool) {
+	if len(n.members) == 0 && len(n.trailing) == 0 {
+		return "[]", true
+	}
+	if len(n.trailing) > 0 {
+		return "", false
+	}
+	for _, m := range n.members {
+		if (m.value.literal == nil && m.value.str == nil) || len(m.comments) > 0 || m.lineComment != "" {
+			return "", false
+		}
+	}
+	var item formatPrinter
+	item.buf.WriteString("[ ")
+	for i, m := range n.members {
+		if i > 0 {
+			item.buf.WriteString(", ")
+		}
+		item.value(m.value, 0, i > 0 && isFormatEnum(n))
+	}
+	item.buf.WriteString(" ]")
+	if item.buf.Len() > formatInlineWidth {
+		return "", false
+	}
+	return item.buf.String(), true
+}
+
+// isFormatEnum checks if the array `n` is an `#enum` or `#enum-i` pattern,
+// whose values are literals.
+func isFormatEnum(n *formatNode) bool {
+	if len(n.members) == 0 || n.members[0].value.str == nil {
+		return false
+	}
+	first := *n.members[0].value.str
+	return isMarker(first, enumMarker) || isMarker(first, enumMarkerI)
+}
+
+// canonicalMarkerSpelling replaces the name of the marker in `s`, if any,
+// with its canonical spelling.
+func canonicalMarkerSpelling(s string) string {
+	isMarker, marker := getMarker(s)
+	if !isMarker {
+		return s
+	}
+	name, arg := marker, ""
+	if i := strings.IndexByte(marker, ' '); i >= 0 {
+		name, arg = marker[:i], marker[i:]
+	}
+	if canonical, ok := markerAliases[name]; ok {
+		return canonical + arg
+	}
+	return s
+}
+
+// quoteFormatString returns `s` as a JSON string literal, without escaping
+// the HTML characters.
+func quoteFormatString(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
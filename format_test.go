@@ -0,0 +1,59 @@
+package matcher_test
+
+import (
+	"errors"
+	"testing"
+
+	matcher "github.com/panta/go-json-matcher"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		jSpec   string
+		opts    matcher.FormatOptions
+		want    string
+		wantErr bool
+	}{
+		{name: "compact", jSpec: `{"b":"#bool","a":[1,2,3],"#key #bool":"#string"}`,
+			want: "{\n  \"b\": \"#boolean\",\n  \"a\": [ 1, 2, 3 ],\n  \"#key #boolean\": \"#string\"\n}\n"},
+		{name: "scalar", jSpec: ` "#bool" `, want: "\"#boolean\"\n"},
+		{name: "empty", jSpec: `{ "a": {}, "b": [ ] }`, want: "{\n  \"a\": {},\n  \"b\": []\n}\n"},
+		{name: "nested", jSpec: `{"a":{"b":[{"c":1}]}}`,
+			want: "{\n  \"a\": {\n    \"b\": [\n      {\n        \"c\": 1\n      }\n    ]\n  }\n}\n"},
+		{name: "long-array", jSpec: `["#array-of", "#regex ^[a-z0-9]+(-[a-z0-9]+)*$", "#regex ^[a-z0-9]+(-[a-z0-9]+)*$"]`,
+			want: "[\n  \"#array-of\",\n  \"#regex ^[a-z0-9]+(-[a-z0-9]+)*$\",\n  \"#regex ^[a-z0-9]+(-[a-z0-9]+)*$\"\n]\n"},
+		{name: "enum-values", jSpec: `{ "e": [ "#enum", "#bool", "x" ] }`,
+			want: "{\n  \"e\": [ \"#enum\", \"#bool\", \"x\" ]\n}\n"},
+		{name: "no-html-escaping", jSpec: `{ "a": "<b> & c" }`, want: "{\n  \"a\": \"<b> & c\"\n}\n"},
+		{name: "comments",
+			jSpec: "// head\n{\n  // the id\n  id: '#uuid', // inline\n\n\n  flag: \"#bool\",\n  /* end */\n}\n",
+			want:  "// head\n{\n  // the id\n  \"id\": \"#uuid\", // inline\n\n  \"flag\": \"#boolean\"\n  /* end */\n}\n"},
+		{name: "relaxed-numbers", jSpec: `{ a: 0x1F, b: .5 }`, want: "{\n  \"a\": 0x1F,\n  \"b\": .5\n}\n"},
+		{name: "sort-keys", jSpec: "{\n  // z\n  z: 1,\n  a: { y: 2, x: 3 }\n}", opts: matcher.FormatOptions{SortKeys: true},
+			want: "{\n  \"a\": {\n    \"x\": 3,\n    \"y\": 2\n  },\n  // z\n  \"z\": 1\n}\n"},
+		{name: "syntax-error", jSpec: `{ a: }`, wantErr: true},
+		{name: "trailing-data", jSpec: `{} {}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := matcher.FormatWithOptions([]byte(tt.jSpec), tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FormatWithOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if !errors.Is(err, matcher.ErrInvalidPattern) {
+					t.Errorf("FormatWithOptions() error = %v, want an ErrInvalidPattern", err)
+				}
+				return
+			}
+			if string(got) != tt.want {
+				t.Errorf("FormatWithOptions() got =\n%s\nwant =\n%s", got, tt.want)
+			}
+			again, err := matcher.FormatWithOptions(got, tt.opts)
+			if err != nil || string(again) != string(got) {
+				t.Errorf("FormatWithOptions() isn't idempotent: got =\n%s\nerror = %v", again, err)
+			}
+		})
+	}
+}
@@ -1,3 +1,17 @@
 module github.com/panta/go-json-matcher
 
 go 1.18
+
+require (
+	github.com/fxamacker/cbor/v2 v2.6.0
+	github.com/vmihailenco/msgpack/v5 v5.3.5
+	golang.org/x/term v0.10.0
+	google.golang.org/protobuf v1.33.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
+	github.com/x448/float16 v0.8.4 // indirect
+	golang.org/x/sys v0.10.0 // indirect
+)
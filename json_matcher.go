@@ -1,19 +1,31 @@
 package matcher
 
 import (
+	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"math"
+	"math/big"
 	"reflect"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
 
-type matcher func(interface{}, interface{}) (bool, error)
+type matcher func(matchState, interface{}, interface{}) (bool, error)
+
+// combinator matches `x` against a pattern in the form `[ "#name", args... ]`.
+type combinator func(st matchState, x interface{}, args []interface{}) (bool, error)
 
 //nolint:gochecknoglobals // an internal global here is more efficient than repeatedly creating the map in a hot path
 var matchers map[reflect.Kind]matcher
 
+//nolint:gochecknoglobals // an internal global here is more efficient than repeatedly creating the map in a hot path
+var combinators map[string]combinator
+
 //nolint:gochecknoinits // the assigned functions refer to `matchers` so we can't assign it directly: we need init()
 func init() {
 	matchers = map[reflect.Kind]matcher{
@@ -26,6 +38,76 @@ func init() {
 		reflect.Slice:   _matchSlice,
 		reflect.Array:   _matchSlice,
 	}
+	combinators = map[string]combinator{
+		"#json":        _matchEmbeddedJSON,
+		"#jwt-claims":  _matchJWTClaims,
+		enumMarker:     _matchEnum(false),
+		enumMarkerI:    _matchEnum(true),
+		objectOfMarker: _matchObjectOf,
+	}
+}
+
+// markerMatcher checks `x` against a marker, `arg` being the marker argument
+// (the part following the first space, empty if missing).
+type markerMatcher func(x interface{}, arg string) (bool, error)
+
+// markerMatchers holds the markers which aren't handled directly by _matchWithMarker.
+//
+//nolint:gochecknoglobals // an internal global here is more efficient than repeatedly creating the map in a hot path
+var markerMatchers = map[string]markerMatcher{
+	"#starts-with":     _matchStartsWith(false),
+	"#starts-with-i":   _matchStartsWith(true),
+	"#ends-with":       _matchEndsWith(false),
+	"#ends-with-i":     _matchEndsWith(true),
+	"#contains":        _matchContains(false),
+	"#contains-i":      _matchContains(true),
+	"#len":             _matchLen,
+	"#nonempty-string": stringFormatMarker(isNonEmptyString),
+	"#email":           stringFormatMarker(isEmail),
+	"#uri":             stringFormatMarker(isURI),
+	"#url":             _matchURL,
+	"#hostname":        stringFormatMarker(isHostname),
+	"#ipv4":            stringFormatMarker(isIPv4),
+	"#ipv6":            stringFormatMarker(isIPv6),
+	"#ip":              stringFormatMarker(isIP),
+	"#cidr":            stringFormatMarker(isCIDR),
+	"#mac":             stringFormatMarker(isMAC),
+	"#base64":          stringFormatMarker(isBase64),
+	"#base64url":       stringFormatMarker(isBase64URL),
+	"#hex":             stringFormatMarker(isHex),
+	"#jwt":             stringFormatMarker(isJWT),
+	"#uuid":            _matchUUID(uuidAnyVersion),
+	"#uuid-v1":         _matchUUID(1),
+	"#uuid-v2":         _matchUUID(2),
+	"#uuid-v3":         _matchUUID(3),
+	"#uuid-v4":         _matchUUID(4),
+	"#uuid-v5":         _matchUUID(5),
+	"#uuid-v6":         _matchUUID(6),
+	"#uuid-v7":         _matchUUID(7),
+	"#uuid-v8":         _matchUUID(8),
+	"#uuid-nil":        _matchUUID(uuidNil),
+	"#ulid":            stringFormatMarker(isULID),
+	"#ksuid":           stringFormatMarker(isKSUID),
+	"#nanoid":          _matchNanoID,
+	"#snowflake":       _matchSnowflake,
+	"#datetime":        _matchDateTime,
+	"#time":            _matchTime,
+	"#literal":         _matchLiteral,
+}
+
+// relativeTimeMarkerMatcher checks `x` against a marker referring to the
+// current time `now`.
+type relativeTimeMarkerMatcher func(now time.Time, x interface{}, arg string) (bool, error)
+
+// relativeTimeMarkerMatchers holds the markers comparing times with the
+// current time.
+//
+//nolint:gochecknoglobals // an internal global here is more efficient than repeatedly creating the map in a hot path
+var relativeTimeMarkerMatchers = map[string]relativeTimeMarkerMatcher{
+	"#datetime-within": _matchDateTimeWithin,
+	"#datetime-near":   _matchDateTimeNear,
+	"#before":          _matchBefore,
+	"#after":           _matchAfter,
 }
 
 // JSONMatches checks if the JSON in `j` provided with the first argument
@@ -38,16 +120,19 @@ func JSONMatches(j []byte, jPatternSpecifier []byte) (bool, error) {
 	var jAny interface{}
 	err := json.Unmarshal(j, &jAny)
 	if err != nil {
-		return false, fmt.Errorf("can't unmarshal left argument: %w", err)
+		return false, withSentinel(ErrInvalidDocument, fmt.Errorf("can't unmarshal left argument: %w", err))
 	}
 
-	var patternSpecAny interface{}
-	err = json.Unmarshal(jPatternSpecifier, &patternSpecAny)
+	p, err := Compile(jPatternSpecifier)
 	if err != nil {
-		return false, fmt.Errorf("can't unmarshal pattern argument: %w", err)
+		return false, err
 	}
 
-	return _match(jAny, patternSpecAny)
+	report, err := p.matchValue(jAny)
+	if err != nil {
+		return false, err
+	}
+	return report.Matches(), nil
 }
 
 // JSONStringMatches checks if the JSON string `j` provided with the first argument
@@ -68,17 +153,26 @@ func _matchZero(x interface{}) (bool, error) {
 	return false, nil
 }
 
-var uuidRe = regexp.MustCompile(`(?i)^[a-f0-9]{8}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{12}$`)
-var uuidV4Re = regexp.MustCompile(`(?i)^[a-f0-9]{8}-[a-f0-9]{4}-4[a-f0-9]{3}-[89aAbB][a-f0-9]{3}-[a-f0-9]{12}$`)
+// protoDurationRe matches the JSON form of google.protobuf.Duration, whose
+// range exceeds the one of time.Duration.
+var protoDurationRe = regexp.MustCompile(`^-?[0-9]+(\.[0-9]{1,9})?s$`)
 
 const (
+	// markerPrefix starts the markers in the canonical form of patterns
+	// (see WithMarkerPrefix).
+	markerPrefix = "#"
+	// escapedMarkerPrefix starts strings which are literals starting with
+	// markerPrefix, rather than markers.
+	escapedMarkerPrefix = markerPrefix + markerPrefix
+
 	ignoreMarker  = "#ignore"
 	nullMarker    = "#null"
 	presentMarker = "#present"
+	arrayOfMarker = "#array-of"
 )
 
 //nolint:funlen,gocognit // reducing the number of statements would reduce legibility in this instance
-func _matchWithMarker(x interface{}, marker string) (bool, error) {
+func _matchWithMarker(st matchState, x interface{}, marker string) (bool, error) {
 	if x == nil && (marker == ignoreMarker || marker == nullMarker || marker == presentMarker) {
 		return true, nil
 	}
@@ -111,7 +205,8 @@ func _matchWithMarker(x interface{}, marker string) (bool, error) {
 		if (xV.Kind() != reflect.Array) && (xV.Kind() != reflect.Slice) {
 			return false, nil
 		}
-		return true, nil
+		_, isBytes := x.([]byte)
+		return !isBytes, nil
 	case "#object":
 		if xV.Kind() != reflect.Map {
 			return false, nil
@@ -125,10 +220,21 @@ func _matchWithMarker(x interface{}, marker string) (bool, error) {
 		}
 		return true, nil
 	case "#number":
-		if (xV.Kind() != reflect.Int64) && (xV.Kind() != reflect.Float64) {
-			return false, nil
+		return isNumberKind(xV.Kind()), nil
+	case "#integer":
+		if isIntegerKind(xV.Kind()) {
+			return true, nil
 		}
-		return true, nil
+		if xV.Kind() == reflect.Float32 || xV.Kind() == reflect.Float64 {
+			f := xV.Float()
+			return !math.IsInf(f, 0) && f == math.Trunc(f), nil
+		}
+		return false, nil
+	case "#float":
+		return xV.Kind() == reflect.Float32 || xV.Kind() == reflect.Float64, nil
+	case "#bytes":
+		_, ok := x.([]byte)
+		return ok, nil
 	case "#string":
 		if xV.Kind() != reflect.String {
 			return false, nil
@@ -153,42 +259,13 @@ func _matchWithMarker(x interface{}, marker string) (bool, error) {
 		}
 		return false, nil
 
-	case "#datetime":
-		if xV.Kind() == reflect.String {
-			xString, ok := x.(string)
-			if !ok {
-				return false, nil
-			}
-			_, err := time.Parse(time.RFC3339, xString)
-			if err == nil {
-				return true, nil
-			}
-			return false, nil
-		} else if xV.Kind() == reflect.Struct {
-			_, ok := x.(time.Time)
-			if ok {
-				return true, nil
-			}
-		}
-		return false, nil
-	case "#uuid":
-		if xV.Kind() != reflect.String {
-			return false, nil
-		}
+	case "#duration":
 		xString, ok := x.(string)
-		if ok {
-			return uuidRe.MatchString(xString), nil
-		}
-		return false, nil
-	case "#uuid-v4":
-		if xV.Kind() != reflect.String {
+		if !ok {
 			return false, nil
 		}
-		xString, ok := x.(string)
-		if ok {
-			return uuidV4Re.MatchString(xString), nil
-		}
-		return false, nil
+		_, err := time.ParseDuration(xString)
+		return err == nil || protoDurationRe.MatchString(xString), nil
 	case "#regex":
 		//nolint:gomnd // the "magic" literal constant 2 here is clearer than a synthetic constant symbol
 		if len(markerParts) != 2 {
@@ -209,39 +286,105 @@ func _matchWithMarker(x interface{}, marker string) (bool, error) {
 		// TODO: "#[num] EXPR"
 	}
 
-	return false, fmt.Errorf("unsupported pattern '%s'", marker)
+	arg := ""
+	//nolint:gomnd // the "magic" literal constant 2 here is clearer than a synthetic constant symbol
+	if len(markerParts) == 2 {
+		arg = markerParts[1]
+	}
+	if m, ok := markerMatchers[markerParts[0]]; ok {
+		matches, err := m(x, arg)
+		if err != nil {
+			return false, fmt.Errorf("invalid %s marker: %w", markerParts[0], err)
+		}
+		return matches, nil
+	}
+	if m, ok := relativeTimeMarkerMatchers[markerParts[0]]; ok {
+		matches, err := m(st.now, x, arg)
+		if err != nil {
+			return false, fmt.Errorf("invalid %s marker: %w", markerParts[0], err)
+		}
+		return matches, nil
+	}
+
+	return false, withSentinel(ErrUnknownMarker, fmt.Errorf("unsupported pattern '%s'", marker))
 }
 
-func _match(x interface{}, spec interface{}) (bool, error) {
+func _match(st matchState, x interface{}, spec interface{}) (bool, error) {
 	specV := reflect.ValueOf(spec)
 	if !specV.IsValid() {
-		return _matchZero(x)
+		matches, err := _matchZero(x)
+		if err == nil && !matches {
+			st.mismatch("expected null, got %s", formatValue(x))
+		}
+		return matches, err
 	}
 
 	if specV.Kind() == reflect.String {
 		isMarker, specMarker := getMarker(spec)
 		if isMarker {
-			return _matchWithMarker(x, specMarker)
+			if name, isParam := getParam(specMarker); isParam {
+				return false, withSentinel(ErrInvalidPattern,
+					fmt.Errorf("unbound parameter %q, see Pattern.Bind", name))
+			}
+			if name, isUse := getUse(specMarker); isUse {
+				def, ok := st.defs[name]
+				if !ok {
+					return false, withSentinel(ErrInvalidPattern, fmt.Errorf("undefined pattern %q", name))
+				}
+				return _match(st, x, def)
+			}
+			if isEnum, name, args := getCompactEnum(specMarker); isEnum {
+				matches, err := combinators[name](st, x, args)
+				if err != nil {
+					return false, fmt.Errorf("invalid %s marker: %w", name, err)
+				}
+				return matches, nil
+			}
+			matches, err := _matchWithMarker(st, x, specMarker)
+			if err == nil && !matches {
+				st.mismatch("%s doesn't match %q", formatValue(x), specMarker)
+			}
+			return matches, err
+		}
+	}
+
+	spec = unescapeLiteral(spec)
+
+	if isCombinator, name, args := getCombinator(spec); isCombinator {
+		matches, err := combinators[name](st, x, args)
+		if err != nil {
+			return false, fmt.Errorf("invalid %s pattern: %w", name, err)
 		}
+		return matches, nil
 	}
 
 	xV := reflect.ValueOf(x)
 	if !xV.IsValid() {
+		st.mismatch("expected %s, got null", formatValue(spec))
 		return false, nil // here we now that spec is non-zero
 	}
 
+	if b, ok := x.([]byte); ok {
+		return _matchBytes(st, b, spec)
+	}
+
+	if isNumberKind(xV.Kind()) && isNumberKind(specV.Kind()) {
+		return _matchNumber(st, x, spec)
+	}
+
 	if xV.Kind() != specV.Kind() {
+		st.mismatch("expected %s, got %s", formatValue(spec), formatValue(x))
 		return false, nil
 	}
 
 	if m, ok := matchers[specV.Kind()]; ok {
-		return m(x, spec)
+		return m(st, x, spec)
 	}
 	tX := reflect.TypeOf(x)
 	return false, fmt.Errorf("unable to compare %v (type: %v) - kind %v is not supported", x, tX, xV.Kind())
 }
 
-func _matchMap(x interface{}, y interface{}) (bool, error) {
+func _matchMap(st matchState, x interface{}, y interface{}) (bool, error) {
 	vX := reflect.ValueOf(x)
 	if vX.Kind() != reflect.Map {
 		return false, fmt.Errorf("wrong kind for left value, expected Map, got %v", vX.Kind())
@@ -252,47 +395,33 @@ func _matchMap(x interface{}, y interface{}) (bool, error) {
 
 	vY := reflect.ValueOf(y)
 
-	matches, err := _matchMapCheckIteratingObject(vX, vY)
-	if err != nil {
-		return false, err
-	}
-
-	if !matches {
-		return false, nil
-	}
-
-	matches, err = _matchMapCheckIteratingSpec(vX, vY)
-	if err != nil {
-		return false, err
-	}
-
-	return matches, nil
+	// keys present only in the object are allowed, so it's enough to
+	// iterate over the spec (which covers every key it shares with the object)
+	return _matchMapCheckIteratingSpec(st, vX, vY)
 }
 
-func _matchMapCheckIteratingObject(vX reflect.Value, vY reflect.Value) (bool, error) {
+func _matchMapCheckIteratingSpec(st matchState, vX reflect.Value, vY reflect.Value) (bool, error) {
 	matches := true
-	iterX := vX.MapRange()
-	for iterX.Next() {
-		ySpecValue := vY.MapIndex(iterX.Key())
-		if !ySpecValue.IsValid() {
-			// missing spec for this key, skip...
+	// iterate in a stable order, so that mismatches are reported in the same
+	// order across runs
+	keysY := vY.MapKeys()
+	sort.Slice(keysY, func(i, j int) bool { return fmt.Sprint(keysY[i].Interface()) < fmt.Sprint(keysY[j].Interface()) })
+	for _, keyY := range keysY {
+		ySpecValue := vY.MapIndex(keyY)
+		if isSpecial, itemMatches, err := _matchSpecialKey(st, vX.Interface(), keyY.String(),
+			ySpecValue.Interface()); isSpecial {
+			if err != nil {
+				return false, err
+			}
+			matches = matches && itemMatches
 			continue
 		}
-		itemMatches, err := _match(iterX.Value().Interface(), ySpecValue.Interface())
-		if err != nil {
-			return false, fmt.Errorf("can't compare map element %v: %w", iterX.Key().Interface(), err)
+		keyX := keyY
+		if key, ok := keyY.Interface().(string); ok {
+			keyX = reflect.ValueOf(unescapeKey(key))
 		}
-		matches = matches && itemMatches
-	}
-	return matches, nil
-}
-
-func _matchMapCheckIteratingSpec(vX reflect.Value, vY reflect.Value) (bool, error) {
-	matches := true
-	iterY := vY.MapRange()
-	for iterY.Next() {
-		ySpecValue := iterY.Value()
-		xValue := vX.MapIndex(iterY.Key())
+		xValue := vX.MapIndex(keyX)
+		itemSt := st.child(fmt.Sprint(keyX.Interface()))
 
 		//nolint:wastedassign // defensive programming here...
 		itemMatches := false
@@ -300,12 +429,21 @@ func _matchMapCheckIteratingSpec(vX reflect.Value, vY reflect.Value) (bool, erro
 			isMarker, marker := getMarker(ySpecValue.Interface())
 			if isMarker {
 				switch marker {
+				case deleteMarker:
+					// a key removed by an overlay matched directly (see AsOverlay)
+					continue
 				case "#notpresent":
 					itemMatches = !xValue.IsValid()
+					if !itemMatches {
+						itemSt.mismatch("unexpected key, got %s", formatValue(xValue.Interface()))
+					}
 					matches = matches && itemMatches
 					continue
 				case presentMarker:
 					itemMatches = xValue.IsValid()
+					if !itemMatches {
+						itemSt.mismatch("missing key")
+					}
 					matches = matches && itemMatches
 					continue
 				}
@@ -314,12 +452,13 @@ func _matchMapCheckIteratingSpec(vX reflect.Value, vY reflect.Value) (bool, erro
 
 		if !isMarker(ySpecValue.Interface(), ignoreMarker) {
 			if !xValue.IsValid() {
+				itemSt.mismatch("missing key, expected %s", formatValue(ySpecValue.Interface()))
 				matches = false
 			} else {
 				var err error
-				itemMatches, err = _match(xValue.Interface(), ySpecValue.Interface())
+				itemMatches, err = _match(itemSt, xValue.Interface(), ySpecValue.Interface())
 				if err != nil {
-					return false, fmt.Errorf("can't compare map element %v: %w", iterY.Key().Interface(), err)
+					return false, fmt.Errorf("can't compare map element %v: %w", keyX.Interface(), err)
 				}
 				matches = matches && itemMatches
 			}
@@ -328,20 +467,81 @@ func _matchMapCheckIteratingSpec(vX reflect.Value, vY reflect.Value) (bool, erro
 	return matches, nil
 }
 
+// _matchSpecialKey handles the object pattern keys which don't refer to a key
+// of the object: `#where`, `#properties` and `#key PATTERN`.
+func _matchSpecialKey(st matchState, x interface{}, key string, spec interface{}) (bool, bool, error) {
+	if key == whereKey {
+		matches, err := _matchWhere(st, x, spec)
+		return true, matches, err
+	}
+	xMap, ok := x.(map[string]interface{})
+	if key == propertiesKey {
+		if !ok {
+			return true, false, fmt.Errorf("%s requires an object with string keys", propertiesKey)
+		}
+		matches, err := _matchProperties(st, xMap, spec)
+		return true, matches, err
+	}
+	if isKeyPattern, keyPattern := getKeyPattern(key); isKeyPattern {
+		if !ok {
+			return true, false, fmt.Errorf("%s requires an object with string keys", keyMarker)
+		}
+		matches, err := _matchKeyPattern(st, xMap, keyPattern, spec)
+		return true, matches, err
+	}
+	return false, false, nil
+}
+
 func getMarker(y interface{}) (bool, string) {
 	specString, ok := y.(string)
-	if ok && strings.HasPrefix(specString, "#") {
+	if ok && strings.HasPrefix(specString, markerPrefix) && !strings.HasPrefix(specString, escapedMarkerPrefix) {
 		return true, specString
 	}
 	return false, ""
 }
 
+// unescapeLiteral returns the literal string represented by `y` when it is
+// an escaped string (`##...` stands for `#...`), or `y` unchanged.
+func unescapeLiteral(y interface{}) interface{} {
+	if specString, ok := y.(string); ok && strings.HasPrefix(specString, escapedMarkerPrefix) {
+		return specString[len(markerPrefix):]
+	}
+	return y
+}
+
+// unescapeKey returns the key of the document matched by the literal object
+// pattern key `key`: as for strings, `##...` stands for `#...`.
+func unescapeKey(key string) string {
+	if strings.HasPrefix(key, escapedMarkerPrefix) {
+		return key[len(markerPrefix):]
+	}
+	return key
+}
+
+// getCombinator checks if `y` is a combinator pattern, that is an array
+// whose first element is the name of a combinator, returning the name and
+// the remaining elements (the arguments).
+func getCombinator(y interface{}) (bool, string, []interface{}) {
+	ySlice, ok := y.([]interface{})
+	if !ok || len(ySlice) == 0 {
+		return false, "", nil
+	}
+	isMarker, name := getMarker(ySlice[0])
+	if !isMarker {
+		return false, "", nil
+	}
+	if _, ok := combinators[name]; !ok {
+		return false, "", nil
+	}
+	return true, name, ySlice[1:]
+}
+
 func isMarker(y interface{}, marker string) bool {
 	isMarker, gotMarker := getMarker(y)
 	return isMarker && marker == gotMarker
 }
 
-func _matchSlice(x interface{}, y interface{}) (bool, error) {
+func _matchSlice(st matchState, x interface{}, y interface{}) (bool, error) {
 	vX := reflect.ValueOf(x)
 	if vX.Kind() != reflect.Slice {
 		return false, fmt.Errorf("wrong kind for left value, expected Slice, got %v", vX.Kind())
@@ -358,11 +558,13 @@ func _matchSlice(x interface{}, y interface{}) (bool, error) {
 	if vY.Len() == 2 {
 		first := vY.Index(0).Interface()
 		isMarker, firstMarker := getMarker(first)
-		if isMarker && firstMarker == "#array-of" {
+		if isMarker && firstMarker == arrayOfMarker {
 			isArrayOf = true
 			arrayOf = vY.Index(1).Interface()
 		}
-	} else if vX.Len() != vY.Len() {
+	}
+	if !isArrayOf && vX.Len() != vY.Len() {
+		st.mismatch("expected array of length %d, got length %d", vY.Len(), vX.Len())
 		return false, nil
 	}
 
@@ -375,7 +577,7 @@ func _matchSlice(x interface{}, y interface{}) (bool, error) {
 		} else {
 			ySpecElem = vY.Index(i).Interface()
 		}
-		itemMatches, err := _match(vX.Index(i).Interface(), ySpecElem)
+		itemMatches, err := _match(st.child(strconv.Itoa(i)), vX.Index(i).Interface(), ySpecElem)
 		if err != nil {
 			return false, fmt.Errorf("can't compare slice element %v: %w", i, err)
 		}
@@ -384,6 +586,73 @@ func _matchSlice(x interface{}, y interface{}) (bool, error) {
 	return matches, nil
 }
 
-func _matchPrimitive(x interface{}, y interface{}) (bool, error) {
-	return reflect.DeepEqual(x, y), nil
+func _matchPrimitive(st matchState, x interface{}, y interface{}) (bool, error) {
+	if !reflect.DeepEqual(x, y) {
+		st.mismatch("expected %s, got %s", formatValue(y), formatValue(x))
+		return false, nil
+	}
+	return true, nil
+}
+
+// _matchNumber compares two numbers by value, regardless of their Go types,
+// so that integers decoded from binary formats can match JSON numbers.
+func _matchNumber(st matchState, x interface{}, y interface{}) (bool, error) {
+	xF, xOk := numberAsBigFloat(reflect.ValueOf(x))
+	yF, yOk := numberAsBigFloat(reflect.ValueOf(y))
+	if !xOk || !yOk || xF.Cmp(yF) != 0 {
+		st.mismatch("expected %s, got %s", formatValue(y), formatValue(x))
+		return false, nil
+	}
+	return true, nil
+}
+
+// numberAsBigFloat returns the exact value of the number in `v`.
+// It returns false for NaN, which isn't equal to any number.
+func numberAsBigFloat(v reflect.Value) (*big.Float, bool) {
+	switch {
+	case isIntegerKind(v.Kind()) && v.CanInt():
+		return new(big.Float).SetInt64(v.Int()), true
+	case isIntegerKind(v.Kind()):
+		return new(big.Float).SetUint64(v.Uint()), true
+	}
+	f := v.Float()
+	if math.IsNaN(f) {
+		return nil, false
+	}
+	return new(big.Float).SetFloat64(f), true
+}
+
+// _matchBytes matches a byte string (as decoded from binary formats like
+// CBOR or MessagePack) against a pattern. A literal string in the pattern
+// matches if it is the base64 encoding (standard or URL-safe, with or
+// without padding) of the same bytes.
+func _matchBytes(st matchState, b []byte, spec interface{}) (bool, error) {
+	specString, ok := spec.(string)
+	if ok {
+		for _, enc := range []*base64.Encoding{
+			base64.StdEncoding.Strict(), base64.RawStdEncoding.Strict(),
+			base64.URLEncoding.Strict(), base64.RawURLEncoding.Strict(),
+		} {
+			decoded, err := enc.DecodeString(specString)
+			if err == nil && bytes.Equal(decoded, b) {
+				return true, nil
+			}
+		}
+	}
+	st.mismatch("expected %s, got byte string %s", formatValue(spec), formatValue(b))
+	return false, nil
+}
+
+func isIntegerKind(k reflect.Kind) bool {
+	//nolint:exhaustive // all the other kinds aren't integers
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	}
+	return false
+}
+
+func isNumberKind(k reflect.Kind) bool {
+	return isIntegerKind(k) || k == reflect.Float32 || k == reflect.Float64
 }
@@ -1,11 +1,17 @@
 package matcher_test
 
 import (
+	"strings"
 	"testing"
 
 	matcher "github.com/panta/go-json-matcher"
 )
 
+// testJWT is the example token of https://jwt.io.
+const testJWT = "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9." +
+	"eyJzdWIiOiIxMjM0NTY3ODkwIiwibmFtZSI6IkpvaG4gRG9lIiwiaWF0IjoxNTE2MjM5MDIyfQ." +
+	"SflKxwRJSMeKKF2QT4fwpMeJf36POk6yJV_adQssw5c"
+
 func TestJsonStringMatches(t *testing.T) {
 	type args struct {
 		j     string
@@ -69,6 +75,30 @@ func TestJsonStringMatches(t *testing.T) {
 			j:     `123.52`,
 			jSpec: `"#number"`,
 		}, want: true},
+		{name: "integer-spec", args: args{
+			j:     `123`,
+			jSpec: `"#integer"`,
+		}, want: true},
+		{name: "integer-spec-float-literal", args: args{
+			j:     `123.0`,
+			jSpec: `"#integer"`,
+		}, want: true},
+		{name: "integer-spec-fail", args: args{
+			j:     `123.52`,
+			jSpec: `"#integer"`,
+		}, want: false},
+		{name: "float-spec-json", args: args{
+			j:     `123`,
+			jSpec: `"#float"`,
+		}, want: true},
+		{name: "float-spec-wrongtype", args: args{
+			j:     `"123.52"`,
+			jSpec: `"#float"`,
+		}, want: false},
+		{name: "bytes-spec-json-string", args: args{
+			j:     `"aGVsbG8="`,
+			jSpec: `"#bytes"`,
+		}, want: false},
 		{name: "number-spec-wrongtype", args: args{
 			j:     `"hello"`,
 			jSpec: `"#number"`,
@@ -121,6 +151,14 @@ func TestJsonStringMatches(t *testing.T) {
 			j:     `[ true, 42, 5.52 ]`,
 			jSpec: `[ true, 42, 5.52, "hello" ]`,
 		}, want: false},
+		{name: "first-array-superset-len-2", args: args{
+			j:     `[ 1, 2, 3 ]`,
+			jSpec: `[ 1, 2 ]`,
+		}, want: false},
+		{name: "first-array-subset-len-2", args: args{
+			j:     `[ 1 ]`,
+			jSpec: `[ 1, 2 ]`,
+		}, want: false},
 		{name: "array-with-bad-pattern", args: args{
 			j:     `[ true, 42, 5.52, "hello" ]`,
 			jSpec: `[ true, 42, 5.52, "#regex *+" ]`,
@@ -144,7 +182,7 @@ func TestJsonStringMatches(t *testing.T) {
 		{name: "array-of-spec-extra-arg", args: args{
 			j:     `[ 12, 42, 5.52, 0, 7 ]`,
 			jSpec: `[ "#array-of", "#number", "uh?" ]`,
-		}, want: false},
+		}, want: false, wantErr: true},
 		{name: "array-of-obj-spec", args: args{
 			j:     `[ { "id": 1, "name": "joe" }, { "id": 1, "name": "jack" } ]`,
 			jSpec: `[ "#array-of", { "id": "#number", "name": "#string" } ]`,
@@ -307,6 +345,342 @@ func TestJsonStringMatches(t *testing.T) {
 			j:     `2012`,
 			jSpec: `"#date"`,
 		}, want: false},
+		{name: "datetime-spec-time-value-nano", args: args{
+			j:     `"2012-09-27T13:42:24.123Z"`,
+			jSpec: `"#datetime"`,
+		}, want: true},
+		{name: "datetime-layout-named", args: args{
+			j:     `"2012-09-27T13:42:24.123456789+02:00"`,
+			jSpec: `"#datetime rfc3339nano"`,
+		}, want: true},
+		{name: "datetime-layout-datetime", args: args{
+			j:     `"2012-09-27 13:42:24"`,
+			jSpec: `"#datetime datetime"`,
+		}, want: true},
+		{name: "datetime-layout-datetime-fail", args: args{
+			j:     `"2012-09-27T13:42:24Z"`,
+			jSpec: `"#datetime datetime"`,
+		}, want: false},
+		{name: "datetime-layout-rfc1123", args: args{
+			j:     `"Thu, 27 Sep 2012 13:42:24 GMT"`,
+			jSpec: `"#datetime rfc1123"`,
+		}, want: true},
+		{name: "datetime-layout-rfc1123-utc", args: args{
+			j:     `"Thu, 27 Sep 2012 13:42:24 GMT"`,
+			jSpec: `"#datetime RFC1123 utc"`,
+		}, want: true},
+		{name: "datetime-layout-rfc1123-utc-fail", args: args{
+			j:     `"Thu, 27 Sep 2012 13:42:24 CEST"`,
+			jSpec: `"#datetime rfc1123 utc"`,
+		}, want: false},
+		{name: "datetime-layout-go", args: args{
+			j:     `"27/09/2012 13:42"`,
+			jSpec: `"#datetime 02/01/2006 15:04"`,
+		}, want: true},
+		{name: "datetime-layout-go-fail", args: args{
+			j:     `"2012-09-27 13:42"`,
+			jSpec: `"#datetime 02/01/2006 15:04"`,
+		}, want: false},
+		{name: "datetime-layout-go-quoted", args: args{
+			j:     `"2012-09-27  13:42"`,
+			jSpec: `"#datetime \"2006-01-02  15:04\""`,
+		}, want: true},
+		{name: "datetime-utc", args: args{
+			j:     `"2012-09-27T13:42:24Z"`,
+			jSpec: `"#datetime utc"`,
+		}, want: true},
+		{name: "datetime-utc-zero-offset", args: args{
+			j:     `"2012-09-27T13:42:24+00:00"`,
+			jSpec: `"#datetime utc"`,
+		}, want: false},
+		{name: "datetime-utc-numeric-layout", args: args{
+			j:     `"Thu, 27 Sep 2012 13:42:24 +0000"`,
+			jSpec: `"#datetime rfc1123z utc"`,
+		}, want: true},
+		{name: "datetime-utc-fail", args: args{
+			j:     `"2012-09-27T13:42:24+02:00"`,
+			jSpec: `"#datetime utc"`,
+		}, want: false},
+		{name: "datetime-offset", args: args{
+			j:     `"2012-09-27T13:42:24+02:00"`,
+			jSpec: `"#datetime offset"`,
+		}, want: true},
+		{name: "datetime-offset-fail", args: args{
+			j:     `"2012-09-27T13:42:24Z"`,
+			jSpec: `"#datetime offset"`,
+		}, want: false},
+		{name: "datetime-unix", args: args{
+			j:     `1348753344`,
+			jSpec: `"#datetime unix"`,
+		}, want: true},
+		{name: "datetime-unix-fraction", args: args{
+			j:     `1348753344.5`,
+			jSpec: `"#datetime unix"`,
+		}, want: true},
+		{name: "datetime-unix-string", args: args{
+			j:     `"1348753344"`,
+			jSpec: `"#datetime unix"`,
+		}, want: true},
+		{name: "datetime-unix-fail", args: args{
+			j:     `"2012-09-27T13:42:24Z"`,
+			jSpec: `"#datetime unix"`,
+		}, want: false},
+		{name: "datetime-unix-fail-hex-float", args: args{
+			j:     `"0x1p30"`,
+			jSpec: `"#datetime unix"`,
+		}, want: false},
+		{name: "datetime-unix-fail-plus-sign", args: args{
+			j:     `"+1348753344"`,
+			jSpec: `"#datetime unix"`,
+		}, want: false},
+		{name: "datetime-unix-fail-overflow", args: args{
+			j:     `1e19`,
+			jSpec: `"#datetime unix"`,
+		}, want: false},
+		{name: "datetime-unix-fail-overflow-string", args: args{
+			j:     `"99999999999999999999"`,
+			jSpec: `"#datetime unix"`,
+		}, want: false},
+		{name: "datetime-unix-ms", args: args{
+			j:     `1348753344123`,
+			jSpec: `"#datetime unix-ms"`,
+		}, want: true},
+		{name: "datetime-unix-ms-fail-fraction", args: args{
+			j:     `1348753344123.5`,
+			jSpec: `"#datetime unix-ms"`,
+		}, want: false},
+		{name: "time-spec", args: args{
+			j:     `"13:42:24"`,
+			jSpec: `"#time"`,
+		}, want: true},
+		{name: "time-spec-fraction", args: args{
+			j:     `"13:42:24.250"`,
+			jSpec: `"#time"`,
+		}, want: true},
+		{name: "time-spec-fail", args: args{
+			j:     `"2012-09-27T13:42:24Z"`,
+			jSpec: `"#time"`,
+		}, want: false},
+		{name: "time-layout", args: args{
+			j:     `"1:42PM"`,
+			jSpec: `"#time kitchen"`,
+		}, want: true},
+		{name: "time-layout-offset", args: args{
+			j:     `"13:42:24+02:00"`,
+			jSpec: `"#time 15:04:05Z07:00 offset"`,
+		}, want: true},
+		{name: "time-layout-offset-fail", args: args{
+			j:     `"13:42:24Z"`,
+			jSpec: `"#time 15:04:05Z07:00 offset"`,
+		}, want: false},
+		{name: "datetime-layout-invalid", args: args{
+			j:     `"2012"`,
+			jSpec: `"#datetime foo"`,
+		}, want: false, wantErr: true},
+		{name: "datetime-utc-without-zone", args: args{
+			j:     `"2012-09-27 13:42:24"`,
+			jSpec: `"#datetime datetime utc"`,
+		}, want: false, wantErr: true},
+		{name: "datetime-unix-offset", args: args{
+			j:     `1348753344`,
+			jSpec: `"#datetime unix offset"`,
+		}, want: false, wantErr: true},
+		{name: "enum", args: args{
+			j:     `"published"`,
+			jSpec: `["#enum", "draft", "published", "archived"]`,
+		}, want: true},
+		{name: "enum-fail", args: args{
+			j:     `"deleted"`,
+			jSpec: `["#enum", "draft", "published", "archived"]`,
+		}, want: false},
+		{name: "enum-fail-case", args: args{
+			j:     `"Draft"`,
+			jSpec: `["#enum", "draft", "published", "archived"]`,
+		}, want: false},
+		{name: "enum-literals", args: args{
+			j:     `2`,
+			jSpec: `["#enum", 1, 2.0, true, null, "x"]`,
+		}, want: true},
+		{name: "enum-literals-null", args: args{
+			j:     `null`,
+			jSpec: `["#enum", 1, true, null]`,
+		}, want: true},
+		{name: "enum-literals-bool", args: args{
+			j:     `false`,
+			jSpec: `["#enum", 1, true, null]`,
+		}, want: false},
+		{name: "enum-literals-fail-type", args: args{
+			j:     `"1"`,
+			jSpec: `["#enum", 1, true, null]`,
+		}, want: false},
+		{name: "enum-i", args: args{
+			j:     `"Draft"`,
+			jSpec: `["#enum-i", "draft", "published"]`,
+		}, want: true},
+		{name: "enum-compact", args: args{
+			j:     `"archived"`,
+			jSpec: `"#enum draft|published|archived"`,
+		}, want: true},
+		{name: "enum-compact-fail", args: args{
+			j:     `"ARCHIVED"`,
+			jSpec: `"#enum draft|published|archived"`,
+		}, want: false},
+		{name: "enum-compact-i", args: args{
+			j:     `"ARCHIVED"`,
+			jSpec: `"#enum-i draft|published|archived"`,
+		}, want: true},
+		{name: "enum-compact-not-number", args: args{
+			j:     `1`,
+			jSpec: `"#enum 1|2"`,
+		}, want: false},
+		{name: "enum-nested", args: args{
+			j:     `{ "status": "draft" }`,
+			jSpec: `{ "status": ["#enum", "draft", "published"] }`,
+		}, want: true},
+		{name: "enum-empty", args: args{
+			j:     `"x"`,
+			jSpec: `["#enum"]`,
+		}, want: false, wantErr: true},
+		{name: "enum-compact-empty", args: args{
+			j:     `"x"`,
+			jSpec: `"#enum"`,
+		}, want: false, wantErr: true},
+		{name: "enum-not-literal", args: args{
+			j:     `"x"`,
+			jSpec: `["#enum", ["x"]]`,
+		}, want: false, wantErr: true},
+		{name: "escaped-literal", args: args{
+			j:     `"#general"`,
+			jSpec: `"##general"`,
+		}, want: true},
+		{name: "escaped-literal-fail", args: args{
+			j:     `"general"`,
+			jSpec: `"##general"`,
+		}, want: false},
+		{name: "escaped-literal-double", args: args{
+			j:     `"##general"`,
+			jSpec: `"###general"`,
+		}, want: true},
+		{name: "escaped-literal-nested", args: args{
+			j:     `{ "channel": "#general", "color": "#ff0000" }`,
+			jSpec: `{ "channel": "##general", "color": "##ff0000" }`,
+		}, want: true},
+		{name: "escaped-key", args: args{
+			j:     `{ "#where": "x", "#properties": "abc" }`,
+			jSpec: `{ "##where": "x", "##properties": "abc" }`,
+		}, want: true},
+		{name: "escaped-key-fail", args: args{
+			j:     `{ "#where": "y" }`,
+			jSpec: `{ "##where": "x" }`,
+		}, want: false},
+		{name: "escaped-key-missing", args: args{
+			j:     `{ "where": "x" }`,
+			jSpec: `{ "##where": "x" }`,
+		}, want: false},
+		{name: "literal", args: args{
+			j:     `"#ff0000"`,
+			jSpec: `"#literal #ff0000"`,
+		}, want: true},
+		{name: "literal-fail", args: args{
+			j:     `"#00ff00"`,
+			jSpec: `"#literal #ff0000"`,
+		}, want: false},
+		{name: "literal-quoted", args: args{
+			j:     `" #x"`,
+			jSpec: `"#literal \" #x\""`,
+		}, want: true},
+		{name: "literal-not-string", args: args{
+			j:     `1`,
+			jSpec: `"#literal 1"`,
+		}, want: false},
+		{name: "object-of", args: args{
+			j:     `{ "5f0c3b9e-1a2b-4c3d-8e4f-5a6b7c8d9e0f": { "n": 1 }, "6a1d4c0f-2b3c-4d4e-9f50-6b7c8d9e0f1a": { "n": 2 } }`,
+			jSpec: `[ "#object-of", "#uuid", { "n": "#number" } ]`,
+		}, want: true},
+		{name: "object-of-empty", args: args{
+			j:     `{}`,
+			jSpec: `[ "#object-of", "#uuid", "#number" ]`,
+		}, want: true},
+		{name: "object-of-fail-key", args: args{
+			j:     `{ "5f0c3b9e-1a2b-4c3d-8e4f-5a6b7c8d9e0f": 1, "x": 2 }`,
+			jSpec: `[ "#object-of", "#uuid", "#number" ]`,
+		}, want: false},
+		{name: "object-of-fail-value", args: args{
+			j:     `{ "5f0c3b9e-1a2b-4c3d-8e4f-5a6b7c8d9e0f": "1" }`,
+			jSpec: `[ "#object-of", "#uuid", "#number" ]`,
+		}, want: false},
+		{name: "object-of-fail-not-object", args: args{
+			j:     `[ 1 ]`,
+			jSpec: `[ "#object-of", "#string", "#number" ]`,
+		}, want: false},
+		{name: "object-of-range", args: args{
+			j:     `{ "a": 1, "b": 2 }`,
+			jSpec: `[ "#object-of", "#string", "#number", "1..2" ]`,
+		}, want: true},
+		{name: "object-of-range-fail", args: args{
+			j:     `{}`,
+			jSpec: `[ "#object-of", "#string", "#number", "1.." ]`,
+		}, want: false},
+		{name: "object-of-literal-key", args: args{
+			j:     `{ "a": 1 }`,
+			jSpec: `[ "#object-of", "a", "#number" ]`,
+		}, want: true},
+		{name: "key-pattern", args: args{
+			j:     `{ "x-id": "1", "x-trace": "t", "other": 3 }`,
+			jSpec: `{ "#key #regex ^x-": "#string" }`,
+		}, want: true},
+		{name: "key-pattern-fail", args: args{
+			j:     `{ "x-id": 1, "other": 3 }`,
+			jSpec: `{ "#key #regex ^x-": "#string" }`,
+		}, want: false},
+		{name: "key-pattern-with-literal", args: args{
+			j:     `{ "id": 1, "x-a": "a" }`,
+			jSpec: `{ "id": "#number", "#key #starts-with x-": "#string" }`,
+		}, want: true},
+		{name: "properties", args: args{
+			j:     `{ "a": 1, "b": 2 }`,
+			jSpec: `{ "a": 1, "#properties": "2" }`,
+		}, want: true},
+		{name: "properties-max-fail", args: args{
+			j:     `{ "a": 1, "b": 2, "c": 3 }`,
+			jSpec: `{ "a": 1, "#properties": "..2" }`,
+		}, want: false},
+		{name: "properties-min-fail", args: args{
+			j:     `{ "a": 1 }`,
+			jSpec: `{ "#properties": "2.." }`,
+		}, want: false},
+		{name: "object-of-no-args", args: args{
+			j:     `{}`,
+			jSpec: `[ "#object-of", "#string" ]`,
+		}, want: false, wantErr: true},
+		{name: "properties-invalid", args: args{
+			j:     `{}`,
+			jSpec: `{ "#properties": 2 }`,
+		}, want: false, wantErr: true},
+		{name: "key-pattern-invalid", args: args{
+			j:     `{ "a": 1 }`,
+			jSpec: `{ "#key #unknown": 1 }`,
+		}, want: false, wantErr: true},
+		{name: "object-of-invalid-range", args: args{
+			j:     `{}`,
+			jSpec: `[ "#object-of", "#string", "#number", "x" ]`,
+		}, want: false, wantErr: true},
+		{name: "duration-spec", args: args{
+			j:     `"1h30m"`,
+			jSpec: `"#duration"`,
+		}, want: true},
+		{name: "duration-spec-proto", args: args{
+			j:     `"-315576000000.000000001s"`,
+			jSpec: `"#duration"`,
+		}, want: true},
+		{name: "duration-spec-fail", args: args{
+			j:     `"90 minutes"`,
+			jSpec: `"#duration"`,
+		}, want: false},
+		{name: "duration-spec-wrongtype", args: args{
+			j:     `90`,
+			jSpec: `"#duration"`,
+		}, want: false},
 		{name: "uuid-spec-v4", args: args{
 			j:     `"a5bf6b35-61b2-4187-8396-463a3d6c742b"`,
 			jSpec: `"#uuid"`,
@@ -343,6 +717,150 @@ func TestJsonStringMatches(t *testing.T) {
 			j:     `123.52`,
 			jSpec: `"#uuid-v4"`,
 		}, want: false},
+		{name: "uuid-spec-uppercase", args: args{
+			j:     `"A5BF6B35-61B2-4187-8396-463A3D6C742B"`,
+			jSpec: `"#uuid"`,
+		}, want: true},
+		{name: "uuid-spec-lowercase", args: args{
+			j:     `"a5bf6b35-61b2-4187-8396-463a3d6c742b"`,
+			jSpec: `"#uuid lowercase"`,
+		}, want: true},
+		{name: "uuid-spec-lowercase-fail", args: args{
+			j:     `"A5BF6B35-61B2-4187-8396-463A3D6C742B"`,
+			jSpec: `"#uuid lowercase"`,
+		}, want: false},
+		{name: "uuid-spec-bad-arg", args: args{
+			j:     `"a5bf6b35-61b2-4187-8396-463a3d6c742b"`,
+			jSpec: `"#uuid uppercase"`,
+		}, want: false, wantErr: true},
+		{name: "uuid-spec-fail-hyphens", args: args{
+			j:     `"a5bf6b3561b2-4187-8396-463a3d6c742b0"`,
+			jSpec: `"#uuid"`,
+		}, want: false},
+		{name: "uuid-v1-spec", args: args{
+			j:     `"f183ee98-07a3-11ed-861d-0242ac120002"`,
+			jSpec: `"#uuid-v1"`,
+		}, want: true},
+		{name: "uuid-v1-spec-fail-v4", args: args{
+			j:     `"a5bf6b35-61b2-4187-8396-463a3d6c742b"`,
+			jSpec: `"#uuid-v1"`,
+		}, want: false},
+		{name: "uuid-v2-spec", args: args{
+			j:     `"000003e8-07a3-21ed-8200-0242ac120002"`,
+			jSpec: `"#uuid-v2"`,
+		}, want: true},
+		{name: "uuid-v3-spec", args: args{
+			j:     `"a3bb189e-8bf9-3888-9912-ace4e6543002"`,
+			jSpec: `"#uuid-v3"`,
+		}, want: true},
+		{name: "uuid-v5-spec", args: args{
+			j:     `"886313e1-3b8a-5372-9b90-0c9aee199e5d"`,
+			jSpec: `"#uuid-v5"`,
+		}, want: true},
+		{name: "uuid-v6-spec", args: args{
+			j:     `"1ec9414c-232a-6b00-b3c8-9e6bdeced846"`,
+			jSpec: `"#uuid-v6"`,
+		}, want: true},
+		{name: "uuid-v7-spec", args: args{
+			j:     `"017f22e2-79b0-7cc3-98c4-dc0c0c07398f"`,
+			jSpec: `"#uuid-v7"`,
+		}, want: true},
+		{name: "uuid-v7-spec-lowercase", args: args{
+			j:     `"017F22E2-79B0-7CC3-98C4-DC0C0C07398F"`,
+			jSpec: `"#uuid-v7 lowercase"`,
+		}, want: false},
+		{name: "uuid-v7-spec-fail-variant", args: args{
+			j:     `"017f22e2-79b0-7cc3-c8c4-dc0c0c07398f"`,
+			jSpec: `"#uuid-v7"`,
+		}, want: false},
+		{name: "uuid-v8-spec", args: args{
+			j:     `"320c3d4d-cc00-875b-8ec9-32d5f69181c0"`,
+			jSpec: `"#uuid-v8"`,
+		}, want: true},
+		{name: "uuid-nil-spec", args: args{
+			j:     `"00000000-0000-0000-0000-000000000000"`,
+			jSpec: `"#uuid-nil"`,
+		}, want: true},
+		{name: "uuid-nil-spec-fail", args: args{
+			j:     `"00000000-0000-0000-0000-000000000001"`,
+			jSpec: `"#uuid-nil"`,
+		}, want: false},
+		{name: "ulid-spec", args: args{
+			j:     `"01ARZ3NDEKTSV4RRFFQ69G5FAV"`,
+			jSpec: `"#ulid"`,
+		}, want: true},
+		{name: "ulid-spec-lowercase", args: args{
+			j:     `"01arz3ndektsv4rrffq69g5fav"`,
+			jSpec: `"#ulid"`,
+		}, want: true},
+		{name: "ulid-spec-fail-alphabet", args: args{
+			j:     `"01ARZ3NDEKTSV4RRFFQ69G5FAU"`,
+			jSpec: `"#ulid"`,
+		}, want: false},
+		{name: "ulid-spec-fail-overflow", args: args{
+			j:     `"81ARZ3NDEKTSV4RRFFQ69G5FAV"`,
+			jSpec: `"#ulid"`,
+		}, want: false},
+		{name: "ulid-spec-fail-length", args: args{
+			j:     `"01ARZ3NDEKTSV4RRFFQ69G5FA"`,
+			jSpec: `"#ulid"`,
+		}, want: false},
+		{name: "ksuid-spec", args: args{
+			j:     `"0ujtsYcgvSTl8PAuAdqWYSMnLOv"`,
+			jSpec: `"#ksuid"`,
+		}, want: true},
+		{name: "ksuid-spec-fail-overflow", args: args{
+			j:     `"zzzzzzzzzzzzzzzzzzzzzzzzzzz"`,
+			jSpec: `"#ksuid"`,
+		}, want: false},
+		{name: "ksuid-spec-fail-alphabet", args: args{
+			j:     `"0ujtsYcgvSTl8PAuAdqWYSMnLO-"`,
+			jSpec: `"#ksuid"`,
+		}, want: false},
+		{name: "nanoid-spec", args: args{
+			j:     `"V1StGXR8_Z5jdHi6B-myT"`,
+			jSpec: `"#nanoid"`,
+		}, want: true},
+		{name: "nanoid-spec-fail-length", args: args{
+			j:     `"V1StGXR8_Z5jdHi6B-my"`,
+			jSpec: `"#nanoid"`,
+		}, want: false},
+		{name: "nanoid-spec-length", args: args{
+			j:     `"V1StGXR8_Z"`,
+			jSpec: `"#nanoid 10"`,
+		}, want: true},
+		{name: "nanoid-spec-range", args: args{
+			j:     `"V1StGXR8_Z5j"`,
+			jSpec: `"#nanoid 8..16"`,
+		}, want: true},
+		{name: "nanoid-spec-fail-alphabet", args: args{
+			j:     `"V1StGXR8+Z5jdHi6B-myT"`,
+			jSpec: `"#nanoid"`,
+		}, want: false},
+		{name: "snowflake-spec", args: args{
+			j:     `"1541815603606036480"`,
+			jSpec: `"#snowflake"`,
+		}, want: true},
+		{name: "snowflake-spec-number", args: args{
+			j:     `175928847299117063`,
+			jSpec: `"#snowflake"`,
+		}, want: true},
+		{name: "snowflake-spec-fail-overflow", args: args{
+			j:     `"18446744073709551616"`,
+			jSpec: `"#snowflake"`,
+		}, want: false},
+		{name: "snowflake-spec-fail-zero", args: args{
+			j:     `"0"`,
+			jSpec: `"#snowflake"`,
+		}, want: false},
+		{name: "snowflake-spec-fail-leading-zero", args: args{
+			j:     `"01541815603606036480"`,
+			jSpec: `"#snowflake"`,
+		}, want: false},
+		{name: "snowflake-spec-fail-negative", args: args{
+			j:     `-5`,
+			jSpec: `"#snowflake"`,
+		}, want: false},
 		{name: "regex-spec", args: args{
 			j:     `"This is fun"`,
 			jSpec: `"#regex ^This is [a-z]{3}$"`,
@@ -363,6 +881,382 @@ func TestJsonStringMatches(t *testing.T) {
 			j:     `42`,
 			jSpec: `"#regex ^This is [a-z]{3}$"`,
 		}, want: false},
+		{name: "starts-with-spec", args: args{
+			j:     `"https://example.com"`,
+			jSpec: `"#starts-with https://"`,
+		}, want: true},
+		{name: "starts-with-spec-fail", args: args{
+			j:     `"http://example.com"`,
+			jSpec: `"#starts-with https://"`,
+		}, want: false},
+		{name: "starts-with-spec-case", args: args{
+			j:     `"HTTPS://example.com"`,
+			jSpec: `"#starts-with https://"`,
+		}, want: false},
+		{name: "starts-with-i-spec", args: args{
+			j:     `"HTTPS://example.com"`,
+			jSpec: `"#starts-with-i https://"`,
+		}, want: true},
+		{name: "starts-with-spec-wrongtype", args: args{
+			j:     `42`,
+			jSpec: `"#starts-with 4"`,
+		}, want: false},
+		{name: "starts-with-spec-missing-arg", args: args{
+			j:     `"foo"`,
+			jSpec: `"#starts-with"`,
+		}, want: false, wantErr: true},
+		{name: "ends-with-spec", args: args{
+			j:     `"report.pdf"`,
+			jSpec: `"#ends-with .pdf"`,
+		}, want: true},
+		{name: "ends-with-spec-fail", args: args{
+			j:     `"report.PDF"`,
+			jSpec: `"#ends-with .pdf"`,
+		}, want: false},
+		{name: "ends-with-i-spec", args: args{
+			j:     `"report.PDF"`,
+			jSpec: `"#ends-with-i .pdf"`,
+		}, want: true},
+		{name: "contains-spec", args: args{
+			j:     `"the quick brown fox"`,
+			jSpec: `"#contains quick brown"`,
+		}, want: true},
+		{name: "contains-spec-fail", args: args{
+			j:     `"the quick brown fox"`,
+			jSpec: `"#contains slow"`,
+		}, want: false},
+		{name: "contains-i-spec", args: args{
+			j:     `"the Quick Brown fox"`,
+			jSpec: `"#contains-i quick brown"`,
+		}, want: true},
+		{name: "contains-spec-quoted", args: args{
+			j:     `"a  b"`,
+			jSpec: `"#contains \" b\""`,
+		}, want: true},
+		{name: "contains-spec-quoted-escapes", args: args{
+			j:     `"say \"hi\""`,
+			jSpec: `"#contains \"\\\"hi\""`,
+		}, want: true},
+		{name: "contains-spec-bad-quoted", args: args{
+			j:     `"foo"`,
+			jSpec: `"#contains \"foo"`,
+		}, want: false, wantErr: true},
+		{name: "len-spec-range", args: args{
+			j:     `"hello"`,
+			jSpec: `"#len 1..255"`,
+		}, want: true},
+		{name: "len-spec-range-fail", args: args{
+			j:     `""`,
+			jSpec: `"#len 1..255"`,
+		}, want: false},
+		{name: "len-spec-exact", args: args{
+			j:     `"héllo"`,
+			jSpec: `"#len 5"`,
+		}, want: true},
+		{name: "len-spec-exact-fail", args: args{
+			j:     `"hello!"`,
+			jSpec: `"#len 5"`,
+		}, want: false},
+		{name: "len-spec-min", args: args{
+			j:     `"hello"`,
+			jSpec: `"#len 3.."`,
+		}, want: true},
+		{name: "len-spec-max", args: args{
+			j:     `"hello"`,
+			jSpec: `"#len ..4"`,
+		}, want: false},
+		{name: "len-spec-array", args: args{
+			j:     `[ 1, 2, 3 ]`,
+			jSpec: `"#len 1..3"`,
+		}, want: true},
+		{name: "len-spec-object", args: args{
+			j:     `{ "a": 1 }`,
+			jSpec: `"#len 2.."`,
+		}, want: false},
+		{name: "len-spec-wrongtype", args: args{
+			j:     `42`,
+			jSpec: `"#len 2"`,
+		}, want: false},
+		{name: "len-spec-bad-range", args: args{
+			j:     `"hello"`,
+			jSpec: `"#len 5..1"`,
+		}, want: false, wantErr: true},
+		{name: "len-spec-bad-bound", args: args{
+			j:     `"hello"`,
+			jSpec: `"#len a..b"`,
+		}, want: false, wantErr: true},
+		{name: "nonempty-string-spec", args: args{
+			j:     `"x"`,
+			jSpec: `"#nonempty-string"`,
+		}, want: true},
+		{name: "nonempty-string-spec-fail", args: args{
+			j:     `""`,
+			jSpec: `"#nonempty-string"`,
+		}, want: false},
+		{name: "nonempty-string-spec-wrongtype", args: args{
+			j:     `[ "x" ]`,
+			jSpec: `"#nonempty-string"`,
+		}, want: false},
+		{name: "nonempty-string-spec-argument", args: args{
+			j:     `"x"`,
+			jSpec: `"#nonempty-string foo"`,
+		}, want: false, wantErr: true},
+		{name: "email-spec", args: args{
+			j:     `"joe@example.com"`,
+			jSpec: `"#email"`,
+		}, want: true},
+		{name: "email-spec-name", args: args{
+			j:     `"Joe <joe@example.com>"`,
+			jSpec: `"#email"`,
+		}, want: false},
+		{name: "email-spec-fail", args: args{
+			j:     `"joe.example.com"`,
+			jSpec: `"#email"`,
+		}, want: false},
+		{name: "email-spec-wrongtype", args: args{
+			j:     `42`,
+			jSpec: `"#email"`,
+		}, want: false},
+		{name: "email-spec-unexpected-arg", args: args{
+			j:     `"joe@example.com"`,
+			jSpec: `"#email example.com"`,
+		}, want: false, wantErr: true},
+		{name: "uri-spec", args: args{
+			j:     `"urn:isbn:0451450523"`,
+			jSpec: `"#uri"`,
+		}, want: true},
+		{name: "uri-spec-relative", args: args{
+			j:     `"/articles/42"`,
+			jSpec: `"#uri"`,
+		}, want: false},
+		{name: "url-spec", args: args{
+			j:     `"https://example.com/articles?id=42"`,
+			jSpec: `"#url"`,
+		}, want: true},
+		{name: "url-spec-no-host", args: args{
+			j:     `"mailto:joe@example.com"`,
+			jSpec: `"#url"`,
+		}, want: false},
+		{name: "url-spec-scheme", args: args{
+			j:     `"https://example.com"`,
+			jSpec: `"#url https"`,
+		}, want: true},
+		{name: "url-spec-scheme-fail", args: args{
+			j:     `"http://example.com"`,
+			jSpec: `"#url https"`,
+		}, want: false},
+		{name: "url-spec-schemes", args: args{
+			j:     `"http://example.com"`,
+			jSpec: `"#url http|https"`,
+		}, want: true},
+		{name: "url-spec-fail", args: args{
+			j:     `"not a url"`,
+			jSpec: `"#url"`,
+		}, want: false},
+		{name: "hostname-spec", args: args{
+			j:     `"api.example.com"`,
+			jSpec: `"#hostname"`,
+		}, want: true},
+		{name: "hostname-spec-fqdn", args: args{
+			j:     `"api.example.com."`,
+			jSpec: `"#hostname"`,
+		}, want: true},
+		{name: "hostname-spec-single-label", args: args{
+			j:     `"localhost"`,
+			jSpec: `"#hostname"`,
+		}, want: true},
+		{name: "hostname-spec-fail-hyphen", args: args{
+			j:     `"-api.example.com"`,
+			jSpec: `"#hostname"`,
+		}, want: false},
+		{name: "hostname-spec-fail-chars", args: args{
+			j:     `"api_v1.example.com"`,
+			jSpec: `"#hostname"`,
+		}, want: false},
+		{name: "hostname-spec-fail-empty-label", args: args{
+			j:     `"api..example.com"`,
+			jSpec: `"#hostname"`,
+		}, want: false},
+		{name: "ipv4-spec", args: args{
+			j:     `"192.168.1.10"`,
+			jSpec: `"#ipv4"`,
+		}, want: true},
+		{name: "ipv4-spec-fail-v6", args: args{
+			j:     `"::1"`,
+			jSpec: `"#ipv4"`,
+		}, want: false},
+		{name: "ipv4-spec-fail", args: args{
+			j:     `"192.168.1.300"`,
+			jSpec: `"#ipv4"`,
+		}, want: false},
+		{name: "ipv6-spec", args: args{
+			j:     `"2001:db8::8a2e:370:7334"`,
+			jSpec: `"#ipv6"`,
+		}, want: true},
+		{name: "ipv6-spec-fail-v4", args: args{
+			j:     `"192.168.1.10"`,
+			jSpec: `"#ipv6"`,
+		}, want: false},
+		{name: "ip-spec-v4", args: args{
+			j:     `"10.0.0.1"`,
+			jSpec: `"#ip"`,
+		}, want: true},
+		{name: "ip-spec-v6", args: args{
+			j:     `"fe80::1"`,
+			jSpec: `"#ip"`,
+		}, want: true},
+		{name: "ip-spec-fail", args: args{
+			j:     `"10.0.0"`,
+			jSpec: `"#ip"`,
+		}, want: false},
+		{name: "cidr-spec-v4", args: args{
+			j:     `"10.0.0.0/8"`,
+			jSpec: `"#cidr"`,
+		}, want: true},
+		{name: "cidr-spec-v6", args: args{
+			j:     `"2001:db8::/32"`,
+			jSpec: `"#cidr"`,
+		}, want: true},
+		{name: "cidr-spec-fail", args: args{
+			j:     `"10.0.0.0"`,
+			jSpec: `"#cidr"`,
+		}, want: false},
+		{name: "cidr-spec-fail-bits", args: args{
+			j:     `"10.0.0.0/33"`,
+			jSpec: `"#cidr"`,
+		}, want: false},
+		{name: "mac-spec", args: args{
+			j:     `"00:1a:2b:3c:4d:5e"`,
+			jSpec: `"#mac"`,
+		}, want: true},
+		{name: "mac-spec-dashes", args: args{
+			j:     `"00-1A-2B-3C-4D-5E"`,
+			jSpec: `"#mac"`,
+		}, want: true},
+		{name: "mac-spec-fail", args: args{
+			j:     `"00:1a:2b:3c:4d"`,
+			jSpec: `"#mac"`,
+		}, want: false},
+		{name: "base64-spec", args: args{
+			j:     `"aGVsbG8gd29ybGQ="`,
+			jSpec: `"#base64"`,
+		}, want: true},
+		{name: "base64-spec-fail-empty", args: args{
+			j:     `""`,
+			jSpec: `"#base64"`,
+		}, want: false},
+		{name: "base64-spec-fail-padding", args: args{
+			j:     `"aGVsbG8gd29ybGQ"`,
+			jSpec: `"#base64"`,
+		}, want: false},
+		{name: "base64-spec-fail-url-alphabet", args: args{
+			j:     `"-_8="`,
+			jSpec: `"#base64"`,
+		}, want: false},
+		{name: "base64-spec-wrongtype", args: args{
+			j:     `42`,
+			jSpec: `"#base64"`,
+		}, want: false},
+		{name: "base64url-spec", args: args{
+			j:     `"-_8"`,
+			jSpec: `"#base64url"`,
+		}, want: true},
+		{name: "base64url-spec-padded", args: args{
+			j:     `"-_8="`,
+			jSpec: `"#base64url"`,
+		}, want: true},
+		{name: "base64url-spec-fail-std-alphabet", args: args{
+			j:     `"+/8="`,
+			jSpec: `"#base64url"`,
+		}, want: false},
+		{name: "base64url-spec-fail-empty", args: args{
+			j:     `""`,
+			jSpec: `"#base64url"`,
+		}, want: false},
+		{name: "hex-spec", args: args{
+			j:     `"deadBEEF00"`,
+			jSpec: `"#hex"`,
+		}, want: true},
+		{name: "hex-spec-fail-odd", args: args{
+			j:     `"abc"`,
+			jSpec: `"#hex"`,
+		}, want: false},
+		{name: "hex-spec-fail", args: args{
+			j:     `"0xabcd"`,
+			jSpec: `"#hex"`,
+		}, want: false},
+		{name: "hex-spec-fail-empty", args: args{
+			j:     `""`,
+			jSpec: `"#hex"`,
+		}, want: false},
+		{name: "jwt-spec", args: args{
+			j:     `"` + testJWT + `"`,
+			jSpec: `"#jwt"`,
+		}, want: true},
+		{name: "jwt-spec-unsigned", args: args{
+			j:     `"eyJhbGciOiJub25lIn0.eyJzdWIiOiJ4In0."`,
+			jSpec: `"#jwt"`,
+		}, want: true},
+		{name: "jwt-spec-fail-parts", args: args{
+			j:     `"` + strings.Join(strings.Split(testJWT, ".")[:2], ".") + `"`,
+			jSpec: `"#jwt"`,
+		}, want: false},
+		{name: "jwt-spec-fail-payload", args: args{
+			j:     `"eyJhbGciOiJub25lIn0.bnVsbA."`,
+			jSpec: `"#jwt"`,
+		}, want: false},
+		{name: "jwt-spec-fail", args: args{
+			j:     `"a.b.c"`,
+			jSpec: `"#jwt"`,
+		}, want: false},
+		{name: "jwt-claims-spec", args: args{
+			j:     `"` + testJWT + `"`,
+			jSpec: `[ "#jwt-claims", { "sub": "#string", "name": "John Doe", "iat": "#number" } ]`,
+		}, want: true},
+		{name: "jwt-claims-spec-fail", args: args{
+			j:     `"` + testJWT + `"`,
+			jSpec: `[ "#jwt-claims", { "admin": true } ]`,
+		}, want: false},
+		{name: "jwt-claims-spec-not-jwt", args: args{
+			j:     `"a.b.c"`,
+			jSpec: `[ "#jwt-claims", "#object" ]`,
+		}, want: false},
+		{name: "jwt-claims-spec-bad-arity", args: args{
+			j:     `"` + testJWT + `"`,
+			jSpec: `[ "#jwt-claims" ]`,
+		}, want: false, wantErr: true},
+		{name: "json-spec", args: args{
+			j:     `"{\"id\": 42, \"tags\": [\"a\"]}"`,
+			jSpec: `[ "#json", { "id": "#number", "tags": [ "#array-of", "#string" ] } ]`,
+		}, want: true},
+		{name: "json-spec-fail", args: args{
+			j:     `"{\"id\": \"42\"}"`,
+			jSpec: `[ "#json", { "id": "#number" } ]`,
+		}, want: false},
+		{name: "json-spec-scalar", args: args{
+			j:     `"42"`,
+			jSpec: `[ "#json", 42 ]`,
+		}, want: true},
+		{name: "json-spec-invalid-json", args: args{
+			j:     `"{id: 42}"`,
+			jSpec: `[ "#json", "#object" ]`,
+		}, want: false},
+		{name: "json-spec-wrongtype", args: args{
+			j:     `{ "id": 42 }`,
+			jSpec: `[ "#json", "#object" ]`,
+		}, want: false},
+		{name: "json-spec-nested", args: args{
+			j:     `{ "payload": "{\"data\": \"[1, 2]\"}" }`,
+			jSpec: `{ "payload": [ "#json", { "data": [ "#json", [ "#array-of", "#number" ] ] } ] }`,
+		}, want: true},
+		{name: "json-spec-bad-arity", args: args{
+			j:     `"42"`,
+			jSpec: `[ "#json", 42, 43 ]`,
+		}, want: false, wantErr: true},
+		{name: "json-spec-bad-subpattern", args: args{
+			j:     `"42"`,
+			jSpec: `[ "#json", "#regex *+" ]`,
+		}, want: false, wantErr: true},
 		{name: "readme-example", args: args{
 			j: `{
   "id": "adb43c69-f8d9-4108-a2da-d740a2a800ec",
@@ -0,0 +1,315 @@
+package matcher
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Severity is the severity of a Diagnostic.
+type Severity string
+
+const (
+	// SeverityError marks a problem which makes the pattern fail to compile,
+	// or makes part of it impossible to match.
+	SeverityError Severity = "error"
+	// SeverityWarning marks a likely mistake.
+	SeverityWarning Severity = "warning"
+)
+
+// The rules checked by Lint, reported in Diagnostic.Rule.
+const (
+	// RuleSyntax reports a pattern which can't be parsed.
+	RuleSyntax = "syntax"
+	// RuleUnknownMarker reports a marker which doesn't exist.
+	RuleUnknownMarker = "unknown-marker"
+	// RuleInvalidPattern reports any other malformed part of a pattern (see
+	// PatternError), e.g. an invalid marker argument or an `#array-of`
+	// pattern with extra items.
+	RuleInvalidPattern = "invalid-pattern"
+	// RuleNotPresentOutsideObject reports a `#notpresent` marker which isn't
+	// the value of an object key, where it can never match.
+	RuleNotPresentOutsideObject = "notpresent-outside-object"
+	// RuleDuplicateKey reports a key repeated in an object, whose last value
+	// silently replaces the others.
+	RuleDuplicateKey = "duplicate-key"
+	// RuleUnreachableEnumValue reports a value repeated in an enum, so that
+	// it can never be the one matching.
+	RuleUnreachableEnumValue = "unreachable-enum-value"
+	// RuleUnanchoredRegex reports a `#regex` whose expression doesn't start
+	// with `^` and end with `$`, so it matches any string merely containing
+	// a match.
+	RuleUnanchoredRegex = "unanchored-regex"
+)
+
+// Diagnostic is a problem found in a pattern by Lint.
+type Diagnostic struct {
+	// Pointer is the JSON Pointer of the offending value within the pattern.
+	Pointer string
+	// Line and Column locate the offending value in the pattern source
+	// (1-based).
+	Line     int
+	Column   int
+	Severity Severity
+	Rule     string
+	Message  string
+}
+
+// String returns the diagnostic formatted as
+// "LINE:COLUMN: SEVERITY: MESSAGE (RULE)".
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%d:%d: %s: %s (%s)", d.Line, d.Column, d.Severity, d.Message, d.Rule)
+}
+
+// Lint checks the JSON pattern specifier in `pattern` for mistakes, some of
+// which Compile would report as errors, while others make the pattern
+// silently behave differently from what was likely meant (see the Rule
+// constants). The diagnostics are sorted by position. The options are the
+// same as for Compile, although only WithMarkerPrefix affects the result.
+func Lint(pattern []byte, opts ...Option) []Diagnostic {
+	var spec interface{}
+	if err := json.Unmarshal(pattern, &spec); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			// the offset is the one after the offending character
+			pos := offsetPosition(pattern, int(syntaxErr.Offset)-1)
+			return []Diagnostic{syntaxDiagnostic(pos, err.Error())}
+		}
+		return []Diagnostic{syntaxDiagnostic(sourcePosition{line: 1, column: 1}, err.Error())}
+	}
+	// JSON is a subset of the relaxed dialect, whose parser tracks positions
+	return LintRelaxed(pattern, opts...)
+}
+
+// LintRelaxed is like Lint for patterns written in the relaxed dialect
+// accepted by CompileRelaxed.
+func LintRelaxed(pattern []byte, opts ...Option) []Diagnostic {
+	p := relaxedParser{src: string(pattern), line: 1, column: 1, positions: map[string]sourcePosition{}}
+	spec, err := p.parse()
+	if err != nil {
+		var syntaxErr *relaxedSyntaxError
+		if errors.As(err, &syntaxErr) {
+			return []Diagnostic{syntaxDiagnostic(syntaxErr.position, syntaxErr.msg)}
+		}
+		return []Diagnostic{syntaxDiagnostic(sourcePosition{line: 1, column: 1}, err.Error())}
+	}
+	return lintSpec(spec, p.positions, p.duplicateKeys, opts)
+}
+
+// LintYAML is like Lint for patterns written in YAML, as accepted by
+// CompileYAML.
+func LintYAML(pattern []byte, opts ...Option) []Diagnostic {
+	// duplicate keys are reported as diagnostics below
+	spec, positions, err := decodeYAML(pattern, true)
+	if err != nil {
+		return []Diagnostic{syntaxDiagnostic(yamlErrorPosition(err), err.Error())}
+	}
+	var root yaml.Node
+	if err := yaml.NewDecoder(bytes.NewReader(pattern)).Decode(&root); err != nil && !errors.Is(err, io.EOF) {
+		return []Diagnostic{syntaxDiagnostic(yamlErrorPosition(err), err.Error())}
+	}
+	return lintSpec(spec, positions, yamlDuplicateKeys(&root, ""), opts)
+}
+
+func syntaxDiagnostic(pos sourcePosition, msg string) Diagnostic {
+	return Diagnostic{Line: pos.line, Column: pos.column, Severity: SeverityError, Rule: RuleSyntax, Message: msg}
+}
+
+// offsetPosition converts the byte offset `offset` in `src` to a position.
+func offsetPosition(src []byte, offset int) sourcePosition {
+	if offset > len(src) {
+		offset = len(src)
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	before := src[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	lineStart := bytes.LastIndexByte(before, '\n') + 1
+	return sourcePosition{line: line, column: len([]rune(string(before[lineStart:]))) + 1}
+}
+
+//nolint:gochecknoglobals // an internal global here is more efficient than repeatedly compiling the regexp
+var yamlErrorLineRe = regexp.MustCompile(`line (\d+)`)
+
+// yamlErrorPosition extracts the line from the message of a YAML error.
+func yamlErrorPosition(err error) sourcePosition {
+	pos := sourcePosition{line: 1, column: 1}
+	if m := yamlErrorLineRe.FindStringSubmatch(err.Error()); m != nil {
+		pos.line, _ = strconv.Atoi(m[1])
+	}
+	return pos
+}
+
+// yamlDuplicateKeys returns the keys repeated in the mappings of `node`.
+func yamlDuplicateKeys(node *yaml.Node, path string) []duplicateKey {
+	var duplicates []duplicateKey
+	//nolint:exhaustive // scalars and aliases can't contain keys
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			duplicates = append(duplicates, yamlDuplicateKeys(child, path)...)
+		}
+	case yaml.SequenceNode:
+		for i, child := range node.Content {
+			duplicates = append(duplicates, yamlDuplicateKeys(child, path+"/"+strconv.Itoa(i))...)
+		}
+	case yaml.MappingNode:
+		seen := map[string]bool{}
+		//nolint:gomnd // the "magic" literal constant 2 here is clearer than a synthetic constant symbol
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			keyNode, valueNode := node.Content[i], node.Content[i+1]
+			if keyNode.ShortTag() == yamlMergeTag {
+				continue
+			}
+			key, err := yamlKeyString(keyNode)
+			if err != nil {
+				continue
+			}
+			keyPath := path + "/" + escapePointerToken(key)
+			if seen[key] {
+				duplicates = append(duplicates, duplicateKey{pointer: keyPath,
+					position: sourcePosition{line: keyNode.Line, column: keyNode.Column}})
+			}
+			seen[key] = true
+			duplicates = append(duplicates, yamlDuplicateKeys(valueNode, keyPath)...)
+		}
+	}
+	return duplicates
+}
+
+// linter collects the diagnostics for a pattern.
+type linter struct {
+	positions   map[string]sourcePosition
+	diagnostics []Diagnostic
+}
+
+func lintSpec(spec interface{}, positions map[string]sourcePosition, duplicates []duplicateKey,
+	opts []Option,
+) []Diagnostic {
+	o, err := newOptions(opts)
+	if err != nil {
+		return []Diagnostic{{Line: 1, Column: 1, Severity: SeverityError, Rule: RuleInvalidPattern, Message: err.Error()}}
+	}
+	spec = canonicalizeMarkers(spec, o.markerPrefix)
+
+	l := linter{positions: positions}
+	for _, d := range duplicates {
+		l.diagnostics = append(l.diagnostics, Diagnostic{Pointer: d.pointer, Line: d.position.line,
+			Column: d.position.column, Severity: SeverityError, Rule: RuleDuplicateKey,
+			Message: "duplicate key, its last value replaces the previous ones"})
+	}
+
+	var problems []*PatternError
+	_ = validator{overlay: o.overlay, problems: &problems}.validate(spec, "")
+	for _, problem := range problems {
+		rule := RuleInvalidPattern
+		if errors.Is(problem.Err, ErrUnknownMarker) {
+			rule = RuleUnknownMarker
+		}
+		l.report(problem.Pointer, SeverityError, rule, "%v", problem.Err)
+	}
+
+	l.lint(spec, "", false)
+
+	sort.SliceStable(l.diagnostics, func(i, j int) bool {
+		a, b := l.diagnostics[i], l.diagnostics[j]
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return l.diagnostics
+}
+
+func (l *linter) report(pointer string, severity Severity, rule string, format string, args ...interface{}) {
+	pos, _ := lookupPosition(l.positions, pointer)
+	l.diagnostics = append(l.diagnostics, Diagnostic{Pointer: pointer, Line: pos.line, Column: pos.column,
+		Severity: severity, Rule: rule, Message: fmt.Sprintf(format, args...)})
+}
+
+// lint checks `spec`, found at `pointer`; `objectValue` is true when it is
+// the value of a key of an object pattern.
+func (l *linter) lint(spec interface{}, pointer string, objectValue bool) {
+	switch v := spec.(type) {
+	case string:
+		l.lintMarker(v, pointer, objectValue)
+	case []interface{}:
+		if isCombinator, name, args := getCombinator(v); isCombinator {
+			if name == enumMarker || name == enumMarkerI {
+				l.lintEnum(args, name == enumMarkerI, func(i int) string { return pointer + "/" + strconv.Itoa(i+1) })
+				return
+			}
+			for i, arg := range args {
+				l.lint(arg, pointer+"/"+strconv.Itoa(i+1), false)
+			}
+			return
+		}
+		for i, item := range v {
+			l.lint(item, pointer+"/"+strconv.Itoa(i), false)
+		}
+	case map[string]interface{}:
+		for _, key := range sortedMapKeys(v) {
+			keyPointer := pointer + "/" + escapePointerToken(key)
+			switch key {
+			case whereKey, propertiesKey, extendsKey:
+				continue
+			case defsKey:
+				if defs, ok := v[key].(map[string]interface{}); ok && pointer == "" {
+					for _, name := range sortedMapKeys(defs) {
+						l.lint(defs[name], keyPointer+"/"+escapePointerToken(name), false)
+					}
+					continue
+				}
+			}
+			if isKeyPattern, keyPattern := getKeyPattern(key); isKeyPattern {
+				l.lintMarker(keyPattern, keyPointer, false)
+				l.lint(v[key], keyPointer, false)
+				continue
+			}
+			l.lint(v[key], keyPointer, true)
+		}
+	}
+}
+
+func (l *linter) lintMarker(s string, pointer string, objectValue bool) {
+	isMarker, marker := getMarker(s)
+	if !isMarker {
+		return
+	}
+	if marker == "#notpresent" && !objectValue {
+		l.report(pointer, SeverityError, RuleNotPresentOutsideObject,
+			"#notpresent only matches a missing object key, so it can never match here")
+	}
+	if expr := strings.TrimPrefix(marker, "#regex "); expr != marker {
+		if !strings.HasPrefix(expr, "^") || !strings.HasSuffix(expr, "$") {
+			l.report(pointer, SeverityWarning, RuleUnanchoredRegex,
+				"regular expression %q matches any string containing a match, anchor it with ^ and $", expr)
+		}
+	}
+	if isEnum, name, args := getCompactEnum(marker); isEnum {
+		l.lintEnum(args, name == enumMarkerI, func(int) string { return pointer })
+	}
+}
+
+// lintEnum checks the values of an enum, `valuePointer` returning the JSON
+// Pointer of the i-th one.
+func (l *linter) lintEnum(values []interface{}, caseInsensitive bool, valuePointer func(i int) string) {
+	for i, value := range values {
+		for _, previous := range values[:i] {
+			if enumValueEquals(value, previous, caseInsensitive) {
+				l.report(valuePointer(i), SeverityWarning, RuleUnreachableEnumValue,
+					"enum value %s is unreachable, it repeats %s", formatValue(value), formatValue(previous))
+				break
+			}
+		}
+	}
+}
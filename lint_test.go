@@ -0,0 +1,84 @@
+package matcher_test
+
+import (
+	"reflect"
+	"testing"
+
+	matcher "github.com/panta/go-json-matcher"
+)
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name  string
+		lint  func(pattern []byte, opts ...matcher.Option) []matcher.Diagnostic
+		jSpec string
+		opts  []matcher.Option
+		want  []string
+	}{
+		{name: "clean", lint: matcher.Lint, jSpec: `{ "id": "#uuid", "code": "#regex ^[A-Z]{3}$", "deleted": "#notpresent" }`,
+			want: []string{}},
+		{name: "syntax", lint: matcher.Lint, jSpec: "{\n  \"id\": \"#uuid\",\n}",
+			want: []string{`3:1: error: invalid character '}' looking for beginning of object key string (syntax)`}},
+		{name: "unknown-marker", lint: matcher.Lint, jSpec: "{\n  \"name\": \"#strnig\"\n}",
+			want: []string{`2:11: error: unsupported pattern '#strnig' (unknown-marker)`}},
+		{name: "invalid-pattern", lint: matcher.Lint, jSpec: `{ "tags": [ "#array-of", "#string", "#number" ] }`,
+			want: []string{`1:11: error: #array-of expects exactly one pattern, got 2 (invalid-pattern)`}},
+		{name: "notpresent", lint: matcher.Lint,
+			jSpec: `{ "a": [ "#notpresent" ], "b": [ "#object-of", "#string", "#notpresent" ], "c": "#notpresent" }`,
+			want: []string{
+				`1:10: error: #notpresent only matches a missing object key, so it can never match here ` +
+					`(notpresent-outside-object)`,
+				`1:59: error: #notpresent only matches a missing object key, so it can never match here ` +
+					`(notpresent-outside-object)`,
+			}},
+		{name: "notpresent-root", lint: matcher.Lint, jSpec: `"#notpresent"`,
+			want: []string{`1:1: error: #notpresent only matches a missing object key, so it can never match here ` +
+				`(notpresent-outside-object)`}},
+		{name: "duplicate-key", lint: matcher.Lint, jSpec: "{\n  \"id\": \"#uuid\",\n  \"id\": \"#string\"\n}",
+			want: []string{`3:3: error: duplicate key, its last value replaces the previous ones (duplicate-key)`}},
+		{name: "unreachable-enum-value", lint: matcher.Lint,
+			jSpec: `{ "a": [ "#enum-i", "x", "y", "X" ], "b": "#enum 1|2|1" }`,
+			want: []string{
+				`1:31: warning: enum value "X" is unreachable, it repeats "x" (unreachable-enum-value)`,
+				`1:43: warning: enum value "1" is unreachable, it repeats "1" (unreachable-enum-value)`,
+			}},
+		{name: "unanchored-regex", lint: matcher.Lint, jSpec: `{ "a": "#regex [0-9]+", "#key #regex ^x": "#string" }`,
+			want: []string{
+				`1:8: warning: regular expression "[0-9]+" matches any string containing a match, anchor it with ^ and $ ` +
+					`(unanchored-regex)`,
+				`1:43: warning: regular expression "^x" matches any string containing a match, anchor it with ^ and $ ` +
+					`(unanchored-regex)`,
+			}},
+		{name: "definitions", lint: matcher.Lint, jSpec: `{ "#defs": { "gone": "#notpresent" }, "x": "#use gone" }`,
+			want: []string{`1:22: error: #notpresent only matches a missing object key, so it can never match here ` +
+				`(notpresent-outside-object)`}},
+		{name: "marker-prefix", lint: matcher.Lint, jSpec: `{ "a": "$regex x", "b": "#regex y" }`,
+			opts: []matcher.Option{matcher.WithMarkerPrefix("$")},
+			want: []string{`1:8: warning: regular expression "x" matches any string containing a match, anchor it with ^ ` +
+				`and $ (unanchored-regex)`}},
+		{name: "relaxed", lint: matcher.LintRelaxed, jSpec: "{\n  // comment\n  id: '#uuid',\n  id: '#uid',\n}",
+			want: []string{
+				`4:3: error: duplicate key, its last value replaces the previous ones (duplicate-key)`,
+				`4:7: error: unsupported pattern '#uid' (unknown-marker)`,
+			}},
+		{name: "relaxed-syntax", lint: matcher.LintRelaxed, jSpec: "{\n  id: #uuid\n}",
+			want: []string{`2:7: error: unexpected '#', expected a value (syntax)`}},
+		{name: "yaml", lint: matcher.LintYAML, jSpec: "id: \"#uuid\"\nid: \"#uuid\"\ntags:\n  - \"#notpresent\"\n",
+			want: []string{
+				`2:1: error: duplicate key, its last value replaces the previous ones (duplicate-key)`,
+				`4:5: error: #notpresent only matches a missing object key, so it can never match here ` +
+					`(notpresent-outside-object)`,
+			}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			for _, d := range tt.lint([]byte(tt.jSpec), tt.opts...) {
+				got = append(got, d.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Lint() got = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,251 @@
+package matcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// includeMarker is replaced by the pattern in another file when loading a
+// library with LoadPatternLibrary, e.g. `#include common/pagination.json`.
+const includeMarker = "#include"
+
+// patternFileExtensions lists the extensions of the files loaded by
+// LoadPatternLibrary.
+//
+//nolint:gochecknoglobals // a read-only lookup table
+var patternFileExtensions = map[string]bool{".json": true, ".yaml": true, ".yml": true}
+
+// LoadPatternLibrary loads all the patterns in the `.json`, `.yaml` and
+// `.yml` files of `fsys` (e.g. an embed.FS, or os.DirFS("testdata/patterns"))
+// into a PatternLibrary. Each pattern is named after its path without the
+// extension (e.g. `common/pagination`), so that it can be referenced with
+// `#use common/pagination`; the definitions in the `#defs` sections of the
+// files are added to the library too.
+// A `#include PATH` string in a pattern is replaced with the pattern in the
+// file at PATH, relative to the root of `fsys` (e.g.
+// `#include common/pagination.json`).
+// The options (e.g. WithMarkerPrefix) apply to all the files.
+// Errors, including missing files, include cycles and undefined references,
+// report the path of the offending file.
+func LoadPatternLibrary(fsys fs.FS, opts ...Option) (*PatternLibrary, error) {
+	o, err := newOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	l := loader{fsys: fsys, opts: o, files: map[string]*patternFile{}}
+
+	err = fs.WalkDir(fsys, ".", func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !patternFileExtensions[path.Ext(filePath)] {
+			return nil
+		}
+		_, err = l.load(filePath)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	lib := NewPatternLibrary()
+	lib.opts = o
+	defFiles := map[string]string{}
+	for _, filePath := range l.sortedPaths() {
+		f := l.files[filePath]
+		if err := l.expand(f); err != nil {
+			return nil, withSentinel(ErrInvalidPattern, err)
+		}
+		name := strings.TrimSuffix(filePath, path.Ext(filePath))
+		if err := lib.define(name, f.spec); err != nil {
+			return nil, withSentinel(ErrInvalidPattern, fmt.Errorf("%s: %w", filePath, err))
+		}
+		defFiles[name] = filePath
+		for _, defName := range sortedMapKeys(f.defs) {
+			if err := lib.define(defName, f.defs[defName]); err != nil {
+				return nil, withSentinel(ErrInvalidPattern, fmt.Errorf("%s: %w", filePath, err))
+			}
+			if _, ok := defFiles[defName]; !ok {
+				defFiles[defName] = filePath
+			}
+		}
+	}
+
+	for _, name := range sortedMapKeys(lib.defs) {
+		resolved, err := resolveDef(name, lib.defs, nil)
+		if err != nil {
+			return nil, withSentinel(ErrInvalidPattern, fmt.Errorf("%s: %w", defFiles[name], err))
+		}
+		lib.defs[name] = resolved
+	}
+	for _, name := range sortedMapKeys(lib.defs) {
+		if err := checkUses(lib.defs[name], lib.defs); err != nil {
+			return nil, withSentinel(ErrInvalidPattern, fmt.Errorf("%s: %w", defFiles[name], err))
+		}
+	}
+	if err := checkCycles(lib.defs); err != nil {
+		return nil, withSentinel(ErrInvalidPattern, err)
+	}
+	return lib, nil
+}
+
+// Pattern returns the pattern named `name`, which can reference all the
+// patterns in the library. The options of the library (those given to
+// LoadPatternLibrary) apply, unless overridden by `opts`; the marker prefix
+// can't be changed, since the patterns are already parsed.
+// The errors match ErrInvalidPattern.
+func (l *PatternLibrary) Pattern(name string, opts ...Option) (*Pattern, error) {
+	spec, ok := l.defs[name]
+	if !ok {
+		return nil, withSentinel(ErrInvalidPattern, fmt.Errorf("undefined pattern %q", name))
+	}
+	o := l.opts
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.markerPrefix != l.opts.markerPrefix {
+		return nil, withSentinel(ErrInvalidPattern,
+			fmt.Errorf("the marker prefix %q of the library can't be changed to %q", l.opts.markerPrefix, o.markerPrefix))
+	}
+	defs := make(map[string]interface{}, len(l.defs))
+	for defName, def := range l.defs {
+		defs[defName] = def
+	}
+	return makePattern(spec, defs, o), nil
+}
+
+type patternFile struct {
+	path     string
+	spec     interface{}
+	defs     map[string]interface{}
+	expanded bool
+}
+
+type loader struct {
+	fsys  fs.FS
+	opts  options
+	files map[string]*patternFile
+	stack []string // the files being expanded, to detect include cycles
+}
+
+func (l *loader) sortedPaths() []string {
+	paths := make([]string, 0, len(l.files))
+	for filePath := range l.files {
+		paths = append(paths, filePath)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// load reads and parses the pattern in the file at `filePath`.
+func (l *loader) load(filePath string) (*patternFile, error) {
+	if f, ok := l.files[filePath]; ok {
+		return f, nil
+	}
+	data, err := fs.ReadFile(l.fsys, filePath)
+	if err != nil {
+		return nil, err
+	}
+	var spec interface{}
+	var positions map[string]sourcePosition
+	if path.Ext(filePath) == ".json" {
+		err = json.Unmarshal(data, &spec)
+	} else {
+		spec, positions, err = unmarshalYAML(data)
+	}
+	if err != nil {
+		return nil, withSentinel(ErrInvalidPattern, fmt.Errorf("%s: can't unmarshal pattern: %w", filePath, err))
+	}
+	spec = canonicalizeMarkers(spec, l.opts.markerPrefix)
+	if err := (validator{includes: true, overlay: l.opts.overlay}).validate(spec, ""); err != nil {
+		return nil, fmt.Errorf("%s: %w", filePath, locatePatternError(err, func() map[string]sourcePosition {
+			if positions == nil {
+				_, positions, _ = unmarshalRelaxed(data)
+			}
+			return positions
+		}))
+	}
+	spec, defs, err := extractDefs(spec, nil)
+	if err != nil {
+		return nil, withSentinel(ErrInvalidPattern, fmt.Errorf("%s: %w", filePath, err))
+	}
+	f := &patternFile{path: filePath, spec: spec, defs: defs}
+	l.files[filePath] = f
+	return f, nil
+}
+
+// expand replaces the `#include` strings in the pattern and definitions of
+// `f` with the (expanded) patterns of the included files.
+func (l *loader) expand(f *patternFile) error {
+	if f.expanded {
+		return nil
+	}
+	for _, p := range l.stack {
+		if p == f.path {
+			return fmt.Errorf("%s: include cycle: %s", l.stack[0], strings.Join(append(l.stack, f.path), " -> "))
+		}
+	}
+	l.stack = append(l.stack, f.path)
+	defer func() { l.stack = l.stack[:len(l.stack)-1] }()
+
+	spec, err := l.expandIncludes(f.path, f.spec)
+	if err != nil {
+		return err
+	}
+	for _, name := range sortedMapKeys(f.defs) {
+		def, err := l.expandIncludes(f.path, f.defs[name])
+		if err != nil {
+			return err
+		}
+		f.defs[name] = def
+	}
+	f.spec, f.expanded = spec, true
+	return nil
+}
+
+func (l *loader) expandIncludes(filePath string, spec interface{}) (interface{}, error) {
+	switch v := spec.(type) {
+	case string:
+		isMarker, marker := getMarker(v)
+		target := strings.TrimPrefix(marker, includeMarker+" ")
+		if !isMarker || target == marker {
+			return v, nil
+		}
+		if !fs.ValidPath(target) {
+			return nil, fmt.Errorf("%s: %s %s: invalid path", filePath, includeMarker, target)
+		}
+		included, err := l.load(target)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s %s: %w", filePath, includeMarker, target, err)
+		}
+		if err := l.expand(included); err != nil {
+			return nil, err
+		}
+		return included.spec, nil
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			expanded, err := l.expandIncludes(filePath, item)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = expanded
+		}
+		return items, nil
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for _, key := range sortedMapKeys(v) {
+			expanded, err := l.expandIncludes(filePath, v[key])
+			if err != nil {
+				return nil, err
+			}
+			m[key] = expanded
+		}
+		return m, nil
+	}
+	return spec, nil
+}
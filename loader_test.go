@@ -0,0 +1,111 @@
+package matcher_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	matcher "github.com/panta/go-json-matcher"
+)
+
+func TestLoadPatternLibrary(t *testing.T) {
+	fsys := fstest.MapFS{
+		"common/pagination.json": {Data: []byte(`{ "page": "#integer", "total_pages": "#integer" }`)},
+		"common/money.yaml":      {Data: []byte("amount: '#number'\ncurrency: '#enum EUR|USD'\n")},
+		"users/list.json": {Data: []byte(`{
+  "#defs": { "user": { "id": "#uuid", "name": "#string", "balance": "#use common/money" } },
+  "items": [ "#array-of", "#use user" ],
+  "pagination": "#include common/pagination.json"
+}`)},
+		"README.md": {Data: []byte(`not a pattern`)},
+	}
+	lib, err := matcher.LoadPatternLibrary(fsys)
+	if err != nil {
+		t.Fatalf("LoadPatternLibrary() error = %v", err)
+	}
+
+	p, err := lib.Pattern("users/list")
+	if err != nil {
+		t.Fatalf("Pattern() error = %v", err)
+	}
+	report, err := p.Match([]byte(`{
+  "items": [
+    { "id": "5f0c3b9e-1a2b-4c3d-8e4f-5a6b7c8d9e0f", "name": "joe", "balance": { "amount": 1, "currency": "GBP" } }
+  ],
+  "pagination": { "page": 1, "total_pages": 1.5 }
+}`))
+	if err != nil {
+		t.Fatalf("Match() error = %v", err)
+	}
+	want := []matcher.Mismatch{
+		{Path: "/items/0/balance/currency", Reason: `expected one of "EUR", "USD", got "GBP"`},
+		{Path: "/pagination/total_pages", Reason: `1.5 doesn't match "#integer"`},
+	}
+	if !reflect.DeepEqual(report.Mismatches, want) {
+		t.Errorf("Match() got = %v, want %v", report.Mismatches, want)
+	}
+
+	other := matcher.MustCompile(`{ "owner": "#use user", "page": "#use common/pagination" }`, matcher.WithLibrary(lib))
+	ok, err := other.Matches([]byte(`{
+  "owner": {
+    "id": "5f0c3b9e-1a2b-4c3d-8e4f-5a6b7c8d9e0f", "name": "ann", "balance": { "amount": 0, "currency": "EUR" }
+  },
+  "page": { "page": 1, "total_pages": 3 }
+}`))
+	if err != nil || !ok {
+		t.Errorf("Matches() got = %v, %v, want true, nil", ok, err)
+	}
+
+	if _, err := lib.Pattern("users/missing"); !errors.Is(err, matcher.ErrInvalidPattern) {
+		t.Errorf("Pattern() error = %v, want an ErrInvalidPattern for an undefined pattern", err)
+	}
+	if _, err := lib.Pattern("users/list", matcher.WithMarkerPrefix("$")); !errors.Is(err, matcher.ErrInvalidPattern) {
+		t.Errorf("Pattern() error = %v, want an ErrInvalidPattern for a different marker prefix", err)
+	}
+	if _, err := lib.Pattern("users/list", matcher.WithMarkerPrefix("#")); err != nil {
+		t.Errorf("Pattern() error = %v, want none for the same marker prefix", err)
+	}
+}
+
+func TestLoadPatternLibraryErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		fsys    fstest.MapFS
+		wantErr string
+	}{
+		{name: "missing-include", fsys: fstest.MapFS{
+			"a.json": {Data: []byte(`{ "b": "#include b.json" }`)},
+		}, wantErr: `a.json: #include b.json: open b.json: file does not exist`},
+		{name: "include-cycle", fsys: fstest.MapFS{
+			"a.json":     {Data: []byte(`{ "b": "#include sub/b.json" }`)},
+			"sub/b.json": {Data: []byte(`[ "#array-of", "#include a.json" ]`)},
+		}, wantErr: `a.json: include cycle: a.json -> sub/b.json -> a.json`},
+		{name: "invalid-include", fsys: fstest.MapFS{
+			"a.json": {Data: []byte(`{ "b": "#include ../b.json" }`)},
+		}, wantErr: `a.json: #include ../b.json: invalid path`},
+		{name: "invalid-json", fsys: fstest.MapFS{
+			"a.json": {Data: []byte(`{ "b": `)},
+		}, wantErr: `a.json: can't unmarshal pattern`},
+		{name: "undefined", fsys: fstest.MapFS{
+			"a.json": {Data: []byte(`{ "b": "#use c" }`)},
+		}, wantErr: `a.json: undefined pattern "c"`},
+		{name: "duplicate-definition", fsys: fstest.MapFS{
+			"a.json": {Data: []byte(`{ "#defs": { "x": 1 } }`)},
+			"b.json": {Data: []byte(`{ "#defs": { "x": 2 } }`)},
+		}, wantErr: `b.json: pattern "x" is already defined`},
+		{name: "reference-cycle", fsys: fstest.MapFS{
+			"a.json": {Data: []byte(`"#use b"`)},
+			"b.json": {Data: []byte(`"#use a"`)},
+		}, wantErr: `cycle of pattern references: a -> b -> a`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := matcher.LoadPatternLibrary(tt.fsys)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("LoadPatternLibrary() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package matcher
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// isBase64 accepts a string encoded with the standard base64 alphabet, with
+// padding (RFC 4648, section 4). The empty string is rejected, as it
+// encodes no data.
+func isBase64(s string) bool {
+	if s == "" {
+		return false
+	}
+	_, err := base64.StdEncoding.Strict().DecodeString(s)
+	return err == nil
+}
+
+// isBase64URL accepts a string encoded with the URL and filename safe base64
+// alphabet (RFC 4648, section 5), with or without padding. The empty string
+// is rejected.
+func isBase64URL(s string) bool {
+	if s == "" {
+		return false
+	}
+	enc := base64.RawURLEncoding
+	if strings.HasSuffix(s, "=") {
+		enc = base64.URLEncoding
+	}
+	_, err := enc.Strict().DecodeString(s)
+	return err == nil
+}
+
+// isHex accepts a non-zero, even number of hexadecimal digits, in any case.
+func isHex(s string) bool {
+	if s == "" {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+// decodeJWT decodes the claims of the JSON Web Token in `s`, without
+// verifying its signature.
+func decodeJWT(s string) (map[string]interface{}, bool) {
+	parts := strings.Split(s, ".")
+	//nolint:gomnd // the "magic" literal constant 3 here is clearer than a synthetic constant symbol
+	if len(parts) != 3 {
+		return nil, false
+	}
+	if _, ok := decodeJWTPart(parts[0]); !ok {
+		return nil, false
+	}
+	claims, ok := decodeJWTPart(parts[1])
+	if !ok {
+		return nil, false
+	}
+	if _, err := base64.RawURLEncoding.Strict().DecodeString(parts[2]); err != nil {
+		return nil, false
+	}
+	return claims, true
+}
+
+// decodeJWTPart decodes a JWT part which must contain a JSON object.
+func decodeJWTPart(part string) (map[string]interface{}, bool) {
+	b, err := base64.RawURLEncoding.Strict().DecodeString(part)
+	if err != nil {
+		return nil, false
+	}
+	var obj map[string]interface{}
+	if err := json.Unmarshal(b, &obj); err != nil || obj == nil {
+		return nil, false
+	}
+	return obj, true
+}
+
+// isJWT accepts a structurally valid JSON Web Token in compact
+// serialization: three base64url encoded parts separated by dots, the first
+// two being JSON objects (the header and the claims). The signature is not
+// verified.
+func isJWT(s string) bool {
+	_, ok := decodeJWT(s)
+	return ok
+}
+
+func combinatorArg(args []interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, errors.New("expected exactly one argument")
+	}
+	return args[0], nil
+}
+
+// _matchEmbeddedJSON matches a string containing serialized JSON against the
+// pattern in `[ "#json", PATTERN ]`.
+func _matchEmbeddedJSON(st matchState, x interface{}, args []interface{}) (bool, error) {
+	spec, err := combinatorArg(args)
+	if err != nil {
+		return false, err
+	}
+	xString, ok := x.(string)
+	if !ok {
+		st.mismatch("expected a string containing JSON, got %s", formatValue(x))
+		return false, nil
+	}
+	var embedded interface{}
+	if err := json.Unmarshal([]byte(xString), &embedded); err != nil {
+		st.mismatch("expected a string containing JSON, got %s", formatValue(x))
+		return false, nil
+	}
+	return _match(st, embedded, spec)
+}
+
+// _matchJWTClaims matches the claims of a JSON Web Token against the pattern
+// in `[ "#jwt-claims", PATTERN ]`. The signature is not verified.
+func _matchJWTClaims(st matchState, x interface{}, args []interface{}) (bool, error) {
+	spec, err := combinatorArg(args)
+	if err != nil {
+		return false, err
+	}
+	xString, ok := x.(string)
+	if !ok {
+		st.mismatch("expected a JWT, got %s", formatValue(x))
+		return false, nil
+	}
+	claims, ok := decodeJWT(xString)
+	if !ok {
+		st.mismatch("expected a JWT, got %s", formatValue(x))
+		return false, nil
+	}
+	return _match(st, claims, spec)
+}
@@ -0,0 +1,76 @@
+package matcher
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const (
+	enumMarker  = "#enum"
+	enumMarkerI = "#enum-i"
+)
+
+// getCompactEnum recognises the compact form of the enum markers
+// (`#enum a|b|c`), returning the marker name and the allowed values.
+func getCompactEnum(marker string) (bool, string, []interface{}) {
+	//nolint:gomnd // the "magic" literal constant 2 here is clearer than a synthetic constant symbol
+	markerParts := strings.SplitN(marker, " ", 2)
+	if markerParts[0] != enumMarker && markerParts[0] != enumMarkerI {
+		return false, "", nil
+	}
+	var args []interface{}
+	//nolint:gomnd // the "magic" literal constant 2 here is clearer than a synthetic constant symbol
+	if len(markerParts) == 2 {
+		for _, value := range strings.Split(markerParts[1], "|") {
+			args = append(args, value)
+		}
+	}
+	return true, markerParts[0], args
+}
+
+// _matchEnum checks that the value is one of the literals in
+// `[ "#enum", VALUE... ]`. Numbers are compared by value; with
+// `caseInsensitive` strings are compared ignoring case.
+func _matchEnum(caseInsensitive bool) combinator {
+	return func(st matchState, x interface{}, args []interface{}) (bool, error) {
+		if len(args) == 0 {
+			return false, errors.New("expected at least one value")
+		}
+		for _, value := range args {
+			switch value.(type) {
+			case map[string]interface{}, []interface{}:
+				return false, fmt.Errorf("values must be literals, got %s", formatValue(value))
+			}
+		}
+		for _, value := range args {
+			if enumValueEquals(x, value, caseInsensitive) {
+				return true, nil
+			}
+		}
+		allowed := make([]string, 0, len(args))
+		for _, value := range args {
+			allowed = append(allowed, formatValue(value))
+		}
+		st.mismatch("expected one of %s, got %s", strings.Join(allowed, ", "), formatValue(x))
+		return false, nil
+	}
+}
+
+func enumValueEquals(x interface{}, value interface{}, caseInsensitive bool) bool {
+	if xString, ok := x.(string); ok {
+		valueString, ok := value.(string)
+		if caseInsensitive {
+			return ok && strings.EqualFold(xString, valueString)
+		}
+		return ok && xString == valueString
+	}
+	xV, valueV := reflect.ValueOf(x), reflect.ValueOf(value)
+	if xV.IsValid() && valueV.IsValid() && isNumberKind(xV.Kind()) && isNumberKind(valueV.Kind()) {
+		xF, xOk := numberAsBigFloat(xV)
+		valueF, valueOk := numberAsBigFloat(valueV)
+		return xOk && valueOk && xF.Cmp(valueF) == 0
+	}
+	return x == value
+}
@@ -0,0 +1,144 @@
+package matcher
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	uuidAnyVersion = 0
+	uuidNil        = -1
+
+	uuidLen        = 36
+	ulidLen        = 26
+	ksuidLen       = 27
+	nanoIDLen      = 21
+	lowercaseArg   = "lowercase"
+	crockfordBase  = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+	ksuidMax       = "aWgEPTl1tmebfsQzFP4bxwgy80V"
+	uuidVariantRFC = 0x80
+)
+
+// parseUUID parses a UUID in its canonical textual form
+// (xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx), in any case.
+func parseUUID(s string) ([16]byte, bool) {
+	var u [16]byte
+	if len(s) != uuidLen || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return u, false
+	}
+	_, err := hex.Decode(u[:], []byte(s[0:8]+s[9:13]+s[14:18]+s[19:23]+s[24:36]))
+	return u, err == nil
+}
+
+// _matchUUID returns a markerMatcher checking that the value is a UUID in
+// canonical form with the given version (and the RFC 4122 / RFC 9562
+// variant), or any UUID for uuidAnyVersion, or the nil UUID for uuidNil.
+// The optional argument `lowercase` requires the lowercase form.
+func _matchUUID(version int) markerMatcher {
+	return func(x interface{}, arg string) (bool, error) {
+		if arg != "" && arg != lowercaseArg {
+			return false, fmt.Errorf("unexpected argument %q (expected %q or nothing)", arg, lowercaseArg)
+		}
+		xString, ok := x.(string)
+		if !ok {
+			return false, nil
+		}
+		u, ok := parseUUID(xString)
+		if !ok || (arg == lowercaseArg && xString != strings.ToLower(xString)) {
+			return false, nil
+		}
+		switch version {
+		case uuidAnyVersion:
+			return true, nil
+		case uuidNil:
+			return u == [16]byte{}, nil
+		}
+		//nolint:gomnd // the "magic" literal constants here are the bit layout described in RFC 9562
+		return int(u[6]>>4) == version && u[8]&0xc0 == uuidVariantRFC, nil
+	}
+}
+
+// isULID accepts a ULID: 26 characters of Crockford's base32 alphabet (in
+// any case), not exceeding the maximum 128-bit value.
+func isULID(s string) bool {
+	if len(s) != ulidLen || s[0] > '7' {
+		return false
+	}
+	for _, c := range strings.ToUpper(s) {
+		if !strings.ContainsRune(crockfordBase, c) {
+			return false
+		}
+	}
+	return true
+}
+
+// isKSUID accepts a KSUID: 27 base62 characters, not exceeding the maximum
+// 160-bit value.
+func isKSUID(s string) bool {
+	return len(s) == ksuidLen && isBase62(s) && s <= ksuidMax
+}
+
+func isBase62(s string) bool {
+	for _, c := range s {
+		if !isBase62Char(c) {
+			return false
+		}
+	}
+	return true
+}
+
+func isBase62Char(c rune) bool {
+	return (c >= '0' && c <= '9') || (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z')
+}
+
+// _matchNanoID checks that the value is a Nano ID using the default
+// alphabet (A-Za-z0-9_-). The optional argument is the required length, as
+// a range (e.g. `#nanoid 10` or `#nanoid 10..32`); the default is 21.
+func _matchNanoID(x interface{}, arg string) (bool, error) {
+	r := intRange{min: nanoIDLen, max: nanoIDLen, hasMax: true}
+	if arg != "" {
+		var err error
+		r, err = parseRange(arg)
+		if err != nil {
+			return false, err
+		}
+	}
+	xString, ok := x.(string)
+	if !ok || !r.contains(len(xString)) {
+		return false, nil
+	}
+	for _, c := range xString {
+		if c != '_' && c != '-' && !isBase62Char(c) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// _matchSnowflake checks that the value is a snowflake ID (as used by
+// Twitter, Discord, ...): a positive 64-bit integer, either as a string of
+// decimal digits (the usual form, to avoid losing precision) or as a number.
+func _matchSnowflake(x interface{}, arg string) (bool, error) {
+	if arg != "" {
+		return false, fmt.Errorf("unexpected argument %q", arg)
+	}
+	if xString, ok := x.(string); ok {
+		n, err := strconv.ParseUint(xString, 10, 64)
+		return err == nil && n > 0 && xString[0] != '0', nil
+	}
+	xV := reflect.ValueOf(x)
+	switch {
+	case isIntegerKind(xV.Kind()) && xV.CanInt():
+		return xV.Int() > 0, nil
+	case isIntegerKind(xV.Kind()):
+		return xV.Uint() > 0, nil
+	case xV.Kind() == reflect.Float64:
+		f := xV.Float()
+		return f > 0 && f == math.Trunc(f) && f <= math.MaxUint64, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,121 @@
+package matcher
+
+import (
+	"fmt"
+	"net"
+	"net/mail"
+	"net/netip"
+	"net/url"
+	"strings"
+)
+
+// stringFormatMarker returns a markerMatcher checking that the value is a
+// string accepted by `valid`. The marker doesn't take arguments.
+func stringFormatMarker(valid func(s string) bool) markerMatcher {
+	return func(x interface{}, arg string) (bool, error) {
+		if arg != "" {
+			return false, fmt.Errorf("unexpected argument %q", arg)
+		}
+		xString, ok := x.(string)
+		if !ok {
+			return false, nil
+		}
+		return valid(xString), nil
+	}
+}
+
+// isEmail accepts a bare email address (as in `joe@example.com`, without
+// display name or angle brackets).
+func isEmail(s string) bool {
+	addr, err := mail.ParseAddress(s)
+	return err == nil && addr.Name == "" && addr.Address == s
+}
+
+// isURI accepts an absolute URI (i.e. having a scheme).
+func isURI(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && u.Scheme != ""
+}
+
+// _matchURL checks that the value is an absolute URL with a host. The
+// optional argument restricts the allowed schemes, separated by `|` (e.g.
+// `#url https` or `#url http|https`).
+func _matchURL(x interface{}, arg string) (bool, error) {
+	var schemes []string
+	if arg != "" {
+		schemes = strings.Split(strings.ToLower(arg), "|")
+	}
+	xString, ok := x.(string)
+	if !ok {
+		return false, nil
+	}
+	u, err := url.Parse(xString)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return false, nil
+	}
+	if len(schemes) == 0 {
+		return true, nil
+	}
+	for _, scheme := range schemes {
+		if strings.EqualFold(u.Scheme, scheme) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+const (
+	maxHostnameLen = 253
+	maxLabelLen    = 63
+)
+
+// isHostname accepts an internet host name as defined by RFC 1123: dot
+// separated labels of letters, digits and hyphens, not starting or ending
+// with a hyphen. A trailing dot (fully qualified name) is allowed.
+func isHostname(s string) bool {
+	s = strings.TrimSuffix(s, ".")
+	if s == "" || len(s) > maxHostnameLen {
+		return false
+	}
+	for _, label := range strings.Split(s, ".") {
+		if label == "" || len(label) > maxLabelLen || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, c := range label {
+			isAlnum := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+			if !isAlnum && c != '-' {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func isIPv4(s string) bool {
+	addr, err := netip.ParseAddr(s)
+	return err == nil && addr.Is4()
+}
+
+func isIPv6(s string) bool {
+	addr, err := netip.ParseAddr(s)
+	return err == nil && addr.Is6()
+}
+
+func isIP(s string) bool {
+	_, err := netip.ParseAddr(s)
+	return err == nil
+}
+
+// isCIDR accepts an IPv4 or IPv6 network in CIDR notation (e.g. `10.0.0.0/8`).
+func isCIDR(s string) bool {
+	_, err := netip.ParsePrefix(s)
+	return err == nil
+}
+
+// isMAC accepts a MAC address (IEEE 802 MAC-48, EUI-48, EUI-64 or a 20-octet
+// IP over InfiniBand link-layer address) in any of the forms accepted by
+// net.ParseMAC.
+func isMAC(s string) bool {
+	_, err := net.ParseMAC(s)
+	return err == nil
+}
@@ -0,0 +1,126 @@
+package matcher
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	objectOfMarker = "#object-of"
+	// keyMarker starts the object pattern keys which match the keys of the
+	// document with a pattern, e.g. `"#key #regex ^x-": "#string"`.
+	keyMarker = "#key"
+	// propertiesKey is the object pattern key constraining the number of
+	// keys of the object, e.g. `"#properties": "1..10"`.
+	propertiesKey = "#properties"
+)
+
+// getKeyPattern checks if the object pattern key `key` is in the form
+// `#key PATTERN`, returning PATTERN.
+func getKeyPattern(key string) (bool, string) {
+	pattern := strings.TrimPrefix(key, keyMarker+" ")
+	if pattern == key {
+		return false, ""
+	}
+	return true, pattern
+}
+
+// matchesSilently checks `x` against `spec` without recording mismatches.
+func matchesSilently(st matchState, x interface{}, spec interface{}) (bool, error) {
+	return _match(st.probe(), x, spec)
+}
+
+func sortedMapKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// _matchKeyPattern checks that the values of all the keys of `x` matching
+// `keyPattern` match `spec`.
+func _matchKeyPattern(st matchState, x map[string]interface{}, keyPattern string, spec interface{}) (bool, error) {
+	matches := true
+	for _, key := range sortedMapKeys(x) {
+		keyMatches, err := matchesSilently(st, key, keyPattern)
+		if err != nil {
+			return false, fmt.Errorf("invalid %s pattern: %w", keyMarker, err)
+		}
+		if !keyMatches {
+			continue
+		}
+		itemMatches, err := _match(st.child(key), x[key], spec)
+		if err != nil {
+			return false, fmt.Errorf("can't compare map element %v: %w", key, err)
+		}
+		matches = matches && itemMatches
+	}
+	return matches, nil
+}
+
+// _matchProperties checks the number of keys of `x` against the range in
+// `spec`.
+func _matchProperties(st matchState, x map[string]interface{}, spec interface{}) (bool, error) {
+	arg, ok := spec.(string)
+	if !ok {
+		return false, fmt.Errorf("%s must be a range like N, N..M, N.. or ..M, got %s", propertiesKey,
+			formatValue(spec))
+	}
+	r, err := parseRange(arg)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s range: %w", propertiesKey, err)
+	}
+	if !r.contains(len(x)) {
+		st.mismatch("expected %s properties, got %d", arg, len(x))
+		return false, nil
+	}
+	return true, nil
+}
+
+// _matchObjectOf checks that all the keys of an object match the key
+// pattern, and all the values match the value pattern, in
+// `[ "#object-of", KEY_PATTERN, VALUE_PATTERN ]`. An optional fourth
+// element is a range constraining the number of keys.
+func _matchObjectOf(st matchState, x interface{}, args []interface{}) (bool, error) {
+	//nolint:gomnd // the "magic" literal constants here are clearer than synthetic constant symbols
+	if len(args) != 2 && len(args) != 3 {
+		return false, errors.New("expected a key pattern, a value pattern and an optional range")
+	}
+	xMap, ok := x.(map[string]interface{})
+	if !ok {
+		st.mismatch("expected an object, got %s", formatValue(x))
+		return false, nil
+	}
+
+	matches := true
+	//nolint:gomnd // the "magic" literal constant 3 here is clearer than a synthetic constant symbol
+	if len(args) == 3 {
+		var err error
+		matches, err = _matchProperties(st, xMap, args[2])
+		if err != nil {
+			return false, err
+		}
+	}
+	for _, key := range sortedMapKeys(xMap) {
+		itemSt := st.child(key)
+		keyMatches, err := matchesSilently(itemSt, key, args[0])
+		if err != nil {
+			return false, fmt.Errorf("invalid key pattern: %w", err)
+		}
+		if !keyMatches {
+			itemSt.mismatch("key %s doesn't match %s", formatValue(key), formatValue(args[0]))
+			matches = false
+			continue
+		}
+		itemMatches, err := _match(itemSt, xMap[key], args[1])
+		if err != nil {
+			return false, fmt.Errorf("can't compare map element %v: %w", key, err)
+		}
+		matches = matches && itemMatches
+	}
+	return matches, nil
+}
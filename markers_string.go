@@ -0,0 +1,154 @@
+package matcher
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// parseMarkerArg returns the string argument of a marker.
+// The argument is everything following the marker name and a single space,
+// taken verbatim (inner and trailing spaces included). When it starts with a
+// double quote, it must be a complete JSON/Go double-quoted string instead,
+// which allows leading spaces, escapes and an empty string.
+func parseMarkerArg(arg string) (string, error) {
+	if !strings.HasPrefix(arg, `"`) {
+		if arg == "" {
+			return "", errors.New("expected exactly one argument")
+		}
+		return arg, nil
+	}
+	unquoted, err := strconv.Unquote(arg)
+	if err != nil {
+		return "", fmt.Errorf("bad quoted argument %s: %w", arg, err)
+	}
+	return unquoted, nil
+}
+
+// stringMarker returns a markerMatcher checking that the value is a string
+// satisfying `check` against the marker argument. When `fold` is true the
+// comparison is case-insensitive.
+func stringMarker(fold bool, check func(s string, arg string) bool) markerMatcher {
+	return func(x interface{}, arg string) (bool, error) {
+		argString, err := parseMarkerArg(arg)
+		if err != nil {
+			return false, err
+		}
+		xString, ok := x.(string)
+		if !ok {
+			return false, nil
+		}
+		if fold {
+			xString, argString = strings.ToLower(xString), strings.ToLower(argString)
+		}
+		return check(xString, argString), nil
+	}
+}
+
+func _matchStartsWith(fold bool) markerMatcher {
+	return stringMarker(fold, strings.HasPrefix)
+}
+
+func _matchEndsWith(fold bool) markerMatcher {
+	return stringMarker(fold, strings.HasSuffix)
+}
+
+func _matchContains(fold bool) markerMatcher {
+	return stringMarker(fold, strings.Contains)
+}
+
+// _matchLiteral checks that the value is the string in `arg`, which is never
+// interpreted as a marker (e.g. `#literal #general`).
+func _matchLiteral(x interface{}, arg string) (bool, error) {
+	literal, err := parseMarkerArg(arg)
+	if err != nil {
+		return false, err
+	}
+	xString, ok := x.(string)
+	return ok && xString == literal, nil
+}
+
+// isNonEmptyString accepts any string but the empty one.
+func isNonEmptyString(s string) bool {
+	return s != ""
+}
+
+// _matchLen checks the length of a string (in characters), an array or an
+// object (in number of keys) against the range in `arg`.
+func _matchLen(x interface{}, arg string) (bool, error) {
+	r, err := parseRange(arg)
+	if err != nil {
+		return false, err
+	}
+	if xString, ok := x.(string); ok {
+		return r.contains(utf8.RuneCountInString(xString)), nil
+	}
+	if _, ok := x.([]byte); ok {
+		return false, nil
+	}
+	xV := reflect.ValueOf(x)
+	//nolint:exhaustive // other kinds don't have a length
+	switch xV.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return r.contains(xV.Len()), nil
+	}
+	return false, nil
+}
+
+// intRange is an inclusive range of non-negative integers, possibly
+// unbounded above.
+type intRange struct {
+	min    int
+	max    int
+	hasMax bool
+}
+
+// parseRange parses a range in the forms `N` (exactly N), `N..M`, `N..`
+// (at least N) or `..M` (at most M).
+func parseRange(s string) (intRange, error) {
+	if s == "" {
+		return intRange{}, errors.New("expected a range argument like N, N..M, N.. or ..M")
+	}
+	lo, hi, isRange := strings.Cut(s, "..")
+	if !isRange {
+		n, err := parseRangeBound(s)
+		if err != nil {
+			return intRange{}, err
+		}
+		return intRange{min: n, max: n, hasMax: true}, nil
+	}
+	r := intRange{}
+	var err error
+	if lo != "" {
+		r.min, err = parseRangeBound(lo)
+		if err != nil {
+			return intRange{}, err
+		}
+	}
+	if hi != "" {
+		r.max, err = parseRangeBound(hi)
+		if err != nil {
+			return intRange{}, err
+		}
+		r.hasMax = true
+		if r.max < r.min {
+			return intRange{}, fmt.Errorf("empty range %q", s)
+		}
+	}
+	return r, nil
+}
+
+func parseRangeBound(s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("bad range bound %q: expected a non-negative integer", s)
+	}
+	return n, nil
+}
+
+func (r intRange) contains(n int) bool {
+	return n >= r.min && (!r.hasMax || n <= r.max)
+}
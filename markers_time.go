@@ -0,0 +1,316 @@
+package matcher
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultTimeLayout = "15:04:05"
+
+	unixFormat   = "unix"
+	unixMsFormat = "unix-ms"
+
+	zoneUTC    = "utc"
+	zoneOffset = "offset"
+)
+
+// namedTimeLayouts maps the names accepted by `#datetime` and `#time` to
+// Go layouts.
+//
+//nolint:gochecknoglobals // an internal global here is more efficient than repeatedly creating the map in a hot path
+var namedTimeLayouts = map[string]string{
+	"rfc3339":     time.RFC3339,
+	"rfc3339nano": time.RFC3339Nano,
+	"rfc1123":     time.RFC1123,
+	"rfc1123z":    time.RFC1123Z,
+	"rfc822":      time.RFC822,
+	"rfc822z":     time.RFC822Z,
+	"rfc850":      time.RFC850,
+	"ansic":       time.ANSIC,
+	"unixdate":    time.UnixDate,
+	"datetime":    "2006-01-02 15:04:05",
+	"kitchen":     time.Kitchen,
+}
+
+// unixTimestampRe matches a unix timestamp given as a string.
+var unixTimestampRe = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+
+// numericOffsetRe matches a numeric zone offset at the end of a timestamp.
+var numericOffsetRe = regexp.MustCompile(`[+-][0-9]{2}(:?[0-9]{2})?$`)
+
+// timeFormat describes how to parse a timestamp, as specified by the
+// arguments of `#datetime` and `#time`.
+type timeFormat struct {
+	layout string // a Go layout, or unixFormat / unixMsFormat
+	zone   string // "", zoneUTC or zoneOffset
+	// numericUTC tells whether the layout formats UTC as a numeric offset
+	// (e.g. `-0700`), rather than as `Z` or as a zone name
+	numericUTC bool
+}
+
+// parseTimeFormat parses a marker argument in the form
+// `[LAYOUT] [utc|offset]`, where LAYOUT is either one of the names in
+// namedTimeLayouts, `unix`, `unix-ms`, or a Go layout (which can be quoted,
+// see parseMarkerArg). `defaultLayout` is used when LAYOUT is missing.
+func parseTimeFormat(arg string, defaultLayout string) (timeFormat, error) {
+	f := timeFormat{layout: defaultLayout}
+	if i := strings.LastIndex(arg, " "); arg == zoneUTC || arg == zoneOffset {
+		f.zone, arg = arg, ""
+	} else if i >= 0 && (arg[i+1:] == zoneUTC || arg[i+1:] == zoneOffset) {
+		f.zone, arg = arg[i+1:], arg[:i]
+	}
+	if arg == "" {
+		return f, nil
+	}
+
+	layout, err := parseMarkerArg(arg)
+	if err != nil {
+		return timeFormat{}, err
+	}
+	if named, ok := namedTimeLayouts[strings.ToLower(layout)]; ok {
+		layout = named
+	}
+	switch {
+	case layout == unixFormat || layout == unixMsFormat:
+		if f.zone == zoneOffset {
+			return timeFormat{}, fmt.Errorf("%s timestamps don't have an offset", layout)
+		}
+	case time.Unix(0, 0).UTC().Format(layout) == layout:
+		return timeFormat{}, fmt.Errorf("layout %q doesn't contain any date or time element", layout)
+	case f.zone != "" && !layoutHasZone(layout):
+		return timeFormat{}, fmt.Errorf("layout %q doesn't contain a time zone, required by %q", layout, f.zone)
+	}
+	f.layout = layout
+	f.numericUTC = numericOffsetRe.MatchString(time.Unix(0, 0).UTC().Format(layout))
+	return f, nil
+}
+
+// layoutHasZone tells whether the Go layout contains a time zone element.
+func layoutHasZone(layout string) bool {
+	// the same wall clock in different zones is formatted differently only
+	// if the layout includes the zone
+	t1 := time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)
+	t2 := time.Date(2006, 1, 2, 15, 4, 5, 0, time.FixedZone("XYZ", 3600)) //nolint:gomnd // any non-zero offset
+	return t1.Format(layout) != t2.Format(layout)
+}
+
+// parse returns the time represented by `x`, which must be a string in the
+// expected layout, a number for the unix formats, or a time.Time (as
+// decoded from binary formats).
+func (f timeFormat) parse(x interface{}) (time.Time, bool) {
+	if t, ok := x.(time.Time); ok {
+		return t, true
+	}
+	if f.layout == unixFormat || f.layout == unixMsFormat {
+		return f.parseUnix(x)
+	}
+	xString, ok := x.(string)
+	if !ok {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(f.layout, xString)
+	if err != nil {
+		return time.Time{}, false
+	}
+	switch f.zone {
+	case zoneUTC:
+		// unknown zone abbreviations are parsed with a zero offset
+		if name, offset := t.Zone(); offset != 0 || (name != "" && name != "UTC" && name != "GMT") {
+			return time.Time{}, false
+		}
+		// UTC must be written as the layout writes it, e.g. `Z` and not
+		// `+00:00` in RFC3339 timestamps
+		if !f.numericUTC && numericOffsetRe.MatchString(xString) {
+			return time.Time{}, false
+		}
+	case zoneOffset:
+		if !numericOffsetRe.MatchString(xString) {
+			return time.Time{}, false
+		}
+	}
+	return t, true
+}
+
+// parseUnix parses a unix timestamp, in seconds (possibly fractional) or
+// milliseconds, given either as a number or as a string of digits.
+func (f timeFormat) parseUnix(x interface{}) (time.Time, bool) {
+	var v float64
+	if xString, ok := x.(string); ok {
+		if !unixTimestampRe.MatchString(xString) {
+			return time.Time{}, false
+		}
+		var err error
+		v, err = strconv.ParseFloat(xString, 64)
+		if err != nil {
+			return time.Time{}, false
+		}
+	} else {
+		xV := reflect.ValueOf(x)
+		if !isNumberKind(xV.Kind()) {
+			return time.Time{}, false
+		}
+		v, _ = numberAsFloat64(xV)
+	}
+	// the seconds (or milliseconds) must fit in an int64, 2^63 is exactly
+	// representable as a float64
+	if math.IsNaN(v) || v < math.MinInt64 || v >= -math.MinInt64 {
+		return time.Time{}, false
+	}
+	if f.layout == unixMsFormat {
+		if v != math.Trunc(v) {
+			return time.Time{}, false
+		}
+		return time.UnixMilli(int64(v)).UTC(), true
+	}
+	sec, frac := math.Modf(v)
+	return time.Unix(int64(sec), int64(frac*float64(time.Second))).UTC(), true
+}
+
+// numberAsFloat64 returns the value of the number in `v` as a float64.
+func numberAsFloat64(v reflect.Value) (float64, bool) {
+	f, ok := numberAsBigFloat(v)
+	if !ok {
+		return math.NaN(), false
+	}
+	result, _ := f.Float64()
+	return result, true
+}
+
+// _matchDateTime checks that the value is a timestamp, by default in
+// RFC3339 format. See parseTimeFormat for the accepted arguments.
+func _matchDateTime(x interface{}, arg string) (bool, error) {
+	f, err := parseTimeFormat(arg, time.RFC3339)
+	if err != nil {
+		return false, err
+	}
+	_, ok := f.parse(x)
+	return ok, nil
+}
+
+// _matchTime checks that the value is a time of day, by default in the
+// `15:04:05` format (fractional seconds are allowed). See parseTimeFormat
+// for the accepted arguments.
+func _matchTime(x interface{}, arg string) (bool, error) {
+	f, err := parseTimeFormat(arg, defaultTimeLayout)
+	if err != nil {
+		return false, err
+	}
+	if _, ok := x.(time.Time); ok {
+		return false, nil
+	}
+	_, ok := f.parse(x)
+	return ok, nil
+}
+
+// parseRelativeTime parses a reference time for `#before` and `#after`:
+// `now` (or nothing) for the current time, a signed duration relative to
+// the current time (e.g. `-1h`), or an RFC3339 datetime.
+func parseRelativeTime(now time.Time, arg string) (time.Time, error) {
+	if arg == "" || arg == "now" {
+		return now, nil
+	}
+	if d, err := time.ParseDuration(arg); err == nil {
+		return now.Add(d), nil
+	}
+	t, err := time.Parse(time.RFC3339, arg)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is neither `now`, a duration nor an RFC3339 datetime", arg)
+	}
+	return t, nil
+}
+
+// parseTolerance parses a tolerance in the form `±DURATION` (or
+// `+-DURATION`).
+func parseTolerance(arg string) (time.Duration, error) {
+	s := strings.TrimPrefix(strings.TrimPrefix(arg, "±"), "+-")
+	if s == arg {
+		return 0, fmt.Errorf("tolerance %q must start with ±", arg)
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("tolerance %q can't be negative", arg)
+	}
+	return d, nil
+}
+
+// parseDefaultDateTime returns the time represented by `x`, which must be an
+// RFC3339 string or a time.Time (as for `#datetime` without arguments).
+func parseDefaultDateTime(x interface{}) (time.Time, bool) {
+	return timeFormat{layout: time.RFC3339}.parse(x)
+}
+
+// _matchDateTimeWithin checks that the value is a datetime within the
+// given duration from `now`: in the past for negative durations (`-5m`), in
+// the future for durations with an explicit plus sign (`+5m`), and in
+// either direction otherwise.
+func _matchDateTimeWithin(now time.Time, x interface{}, arg string) (bool, error) {
+	d, err := time.ParseDuration(arg)
+	if err != nil {
+		return false, err
+	}
+	from, to := now.Add(-d), now.Add(d)
+	switch {
+	case strings.HasPrefix(arg, "-"):
+		from, to = now.Add(d), now
+	case strings.HasPrefix(arg, "+"):
+		from = now
+	}
+	t, ok := parseDefaultDateTime(x)
+	return ok && !t.Before(from) && !t.After(to), nil
+}
+
+// _matchDateTimeNear checks that the value is a datetime close to `now`
+// shifted by an offset, the argument being in the form
+// `OFFSET ±TOLERANCE` (e.g. `+1h ±30s`).
+func _matchDateTimeNear(now time.Time, x interface{}, arg string) (bool, error) {
+	fields := strings.Fields(arg)
+	//nolint:gomnd // the "magic" literal constant 2 here is clearer than a synthetic constant symbol
+	if len(fields) != 2 {
+		return false, fmt.Errorf("expected `OFFSET ±TOLERANCE`, got %q", arg)
+	}
+	offset, err := time.ParseDuration(fields[0])
+	if err != nil {
+		return false, err
+	}
+	tolerance, err := parseTolerance(fields[1])
+	if err != nil {
+		return false, err
+	}
+	t, ok := parseDefaultDateTime(x)
+	if !ok {
+		return false, nil
+	}
+	delta := t.Sub(now.Add(offset))
+	return delta >= -tolerance && delta <= tolerance, nil
+}
+
+// _matchBefore checks that the value is a datetime strictly before the
+// reference time (see parseRelativeTime).
+func _matchBefore(now time.Time, x interface{}, arg string) (bool, error) {
+	ref, err := parseRelativeTime(now, arg)
+	if err != nil {
+		return false, err
+	}
+	t, ok := parseDefaultDateTime(x)
+	return ok && t.Before(ref), nil
+}
+
+// _matchAfter checks that the value is a datetime strictly after the
+// reference time (see parseRelativeTime).
+func _matchAfter(now time.Time, x interface{}, arg string) (bool, error) {
+	ref, err := parseRelativeTime(now, arg)
+	if err != nil {
+		return false, err
+	}
+	t, ok := parseDefaultDateTime(x)
+	return ok && t.After(ref), nil
+}
@@ -0,0 +1,78 @@
+package matcher_test
+
+import (
+	"testing"
+	"time"
+
+	matcher "github.com/panta/go-json-matcher"
+)
+
+func TestRelativeTimeMarkers(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	tests := []struct {
+		name    string
+		j       string
+		jSpec   string
+		want    bool
+		wantErr bool
+	}{
+		{name: "within", j: `"2024-05-01T11:57:00Z"`, jSpec: `"#datetime-within 5m"`, want: true},
+		{name: "within-future", j: `"2024-05-01T12:03:00Z"`, jSpec: `"#datetime-within 5m"`, want: true},
+		{name: "within-offset", j: `"2024-05-01T13:57:00+02:00"`, jSpec: `"#datetime-within 5m"`, want: true},
+		{name: "within-fail", j: `"2024-05-01T11:50:00Z"`, jSpec: `"#datetime-within 5m"`, want: false},
+		{name: "within-past", j: `"2024-05-01T11:57:00Z"`, jSpec: `"#datetime-within -5m"`, want: true},
+		{name: "within-past-fail", j: `"2024-05-01T12:03:00Z"`, jSpec: `"#datetime-within -5m"`, want: false},
+		{name: "within-future-only", j: `"2024-05-01T12:03:00Z"`, jSpec: `"#datetime-within +5m"`, want: true},
+		{name: "within-future-only-fail", j: `"2024-05-01T11:57:00Z"`, jSpec: `"#datetime-within +5m"`, want: false},
+		{name: "within-not-datetime", j: `"yesterday"`, jSpec: `"#datetime-within 5m"`, want: false},
+		{name: "within-invalid", j: `"2024-05-01T12:00:00Z"`, jSpec: `"#datetime-within soon"`, wantErr: true},
+		{name: "near", j: `"2024-05-01T13:00:20Z"`, jSpec: `"#datetime-near +1h ±30s"`, want: true},
+		{name: "near-ascii", j: `"2024-05-01T12:59:40Z"`, jSpec: `"#datetime-near 1h +-30s"`, want: true},
+		{name: "near-fail", j: `"2024-05-01T13:01:00Z"`, jSpec: `"#datetime-near +1h ±30s"`, want: false},
+		{name: "near-past", j: `"2024-04-30T12:00:00Z"`, jSpec: `"#datetime-near -24h ±1m"`, want: true},
+		{name: "near-missing-tolerance", j: `"2024-05-01T13:00:00Z"`, jSpec: `"#datetime-near +1h"`, wantErr: true},
+		{name: "near-invalid-tolerance", j: `"2024-05-01T13:00:00Z"`, jSpec: `"#datetime-near +1h 30s"`, wantErr: true},
+		{name: "before", j: `"2024-05-01T11:59:59Z"`, jSpec: `"#before"`, want: true},
+		{name: "before-fail", j: `"2024-05-01T12:00:00Z"`, jSpec: `"#before now"`, want: false},
+		{name: "before-duration", j: `"2024-05-01T10:59:00Z"`, jSpec: `"#before -1h"`, want: true},
+		{name: "before-datetime", j: `"2023-12-31T23:59:59Z"`, jSpec: `"#before 2024-01-01T00:00:00Z"`, want: true},
+		{name: "after", j: `"2024-05-01T12:00:01Z"`, jSpec: `"#after"`, want: true},
+		{name: "after-fail", j: `"2024-05-01T11:00:00Z"`, jSpec: `"#after -30m"`, want: false},
+		{name: "after-not-datetime", j: `1714564800`, jSpec: `"#after"`, want: false},
+		{name: "after-invalid", j: `"2024-05-01T12:00:01Z"`, jSpec: `"#after tomorrow"`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := matcher.Compile([]byte(tt.jSpec), matcher.WithClock(clock))
+			if err != nil {
+				if !tt.wantErr {
+					t.Errorf("Compile() error = %v", err)
+				}
+				return
+			}
+			got, err := p.Matches([]byte(tt.j))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Matches() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Matches() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRelativeTimeMarkersDefaultClock(t *testing.T) {
+	j := []byte(`{ "created": "` + time.Now().UTC().Add(-time.Minute).Format(time.RFC3339) + `" }`)
+	ok, err := matcher.MustCompile(`{ "created": "#datetime-within -5m" }`).Matches(j)
+	if err != nil || !ok {
+		t.Errorf("Matches() got = %v, %v, want true, nil", ok, err)
+	}
+	// a nil clock falls back to time.Now
+	ok, err = matcher.MustCompile(`{ "created": "#datetime-within -5m" }`, matcher.WithClock(nil)).Matches(j)
+	if err != nil || !ok {
+		t.Errorf("Matches() with a nil clock got = %v, %v, want true, nil", ok, err)
+	}
+}
@@ -0,0 +1,198 @@
+package matcher
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// extendsKey is the object pattern key naming the definitions the object
+	// pattern is merged into, e.g. `"#extends": "article"`.
+	extendsKey = "#extends"
+	// deleteMarker removes a key of the base pattern when merging.
+	deleteMarker = "#delete"
+)
+
+// Merge returns a pattern combining `base` and `overlay`, with a deep merge:
+//   - objects are merged key by key, recursively;
+//   - a key whose value in `overlay` is `#delete` is removed from the result;
+//   - the `#where` expressions of both objects must hold;
+//   - any other value, arrays included, in `overlay` replaces the value in
+//     `base`.
+//
+// The definitions of both patterns are available to the result, those of
+// `overlay` taking precedence, as do its options.
+func Merge(base *Pattern, overlay *Pattern) *Pattern {
+	defs := make(map[string]interface{}, len(base.defs)+len(overlay.defs))
+	for name, def := range base.defs {
+		defs[name] = def
+	}
+	for name, def := range overlay.defs {
+		defs[name] = def
+	}
+	opts := overlay.opts
+	// the deletions of the overlay are applied, so the result is an overlay
+	// only if the base is
+	opts.overlay = base.opts.overlay
+	return makePattern(mergeSpecs(base.spec, overlay.spec), defs, opts)
+}
+
+func mergeSpecs(base interface{}, overlay interface{}) interface{} {
+	baseMap, baseIsMap := base.(map[string]interface{})
+	overlayMap, overlayIsMap := overlay.(map[string]interface{})
+	if !overlayIsMap {
+		return overlay
+	}
+	if !baseIsMap {
+		// nothing to merge with, but the deletions must still go
+		return removeDeletions(overlay)
+	}
+	merged := make(map[string]interface{}, len(baseMap)+len(overlayMap))
+	for key, value := range baseMap {
+		merged[key] = value
+	}
+	for key, value := range overlayMap {
+		if isMarker(value, deleteMarker) {
+			delete(merged, key)
+			continue
+		}
+		if baseValue, ok := merged[key]; ok {
+			if key == whereKey {
+				merged[key] = mergeWhere(baseValue, value)
+				continue
+			}
+			merged[key] = mergeSpecs(baseValue, value)
+			continue
+		}
+		merged[key] = removeDeletions(value)
+	}
+	return merged
+}
+
+// mergeWhere returns the `#where` expressions of both `base` and `overlay`,
+// without repeating those they have in common.
+func mergeWhere(base interface{}, overlay interface{}) interface{} {
+	var merged []interface{}
+	seen := map[string]bool{}
+	for _, spec := range []interface{}{base, overlay} {
+		exprs, ok := spec.([]interface{})
+		if !ok {
+			exprs = []interface{}{spec}
+		}
+		for _, expr := range exprs {
+			if source, ok := expr.(string); ok {
+				if seen[source] {
+					continue
+				}
+				seen[source] = true
+			}
+			merged = append(merged, expr)
+		}
+	}
+	return merged
+}
+
+// removeDeletions removes the keys marked with `#delete` from the objects in
+// `spec`, which have nothing to delete from.
+func removeDeletions(spec interface{}) interface{} {
+	return mergeSpecs(map[string]interface{}{}, spec)
+}
+
+// resolveExtends merges the object patterns with an `#extends` key in `spec`
+// into the definitions they name. `extending` holds the names of the
+// definitions being resolved, to detect cycles.
+func resolveExtends(spec interface{}, defs map[string]interface{}, extending []string) (interface{}, error) {
+	switch v := spec.(type) {
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			resolved, err := resolveExtends(item, defs, extending)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = resolved
+		}
+		return items, nil
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			if key == extendsKey || key == whereKey {
+				m[key] = value
+				continue
+			}
+			resolved, err := resolveExtends(value, defs, extending)
+			if err != nil {
+				return nil, err
+			}
+			m[key] = resolved
+		}
+		extends, ok := m[extendsKey]
+		if !ok {
+			return m, nil
+		}
+		delete(m, extendsKey)
+		names, err := extendsNames(extends)
+		if err != nil {
+			return nil, err
+		}
+		var merged interface{} = map[string]interface{}{}
+		for _, name := range names {
+			base, err := resolveDef(name, defs, extending)
+			if err != nil {
+				return nil, err
+			}
+			merged = mergeSpecs(merged, base)
+		}
+		return mergeSpecs(merged, m), nil
+	}
+	return spec, nil
+}
+
+// resolveDef returns the definition `name` with its `#extends` resolved.
+func resolveDef(name string, defs map[string]interface{}, extending []string) (interface{}, error) {
+	for _, n := range extending {
+		if n == name {
+			return nil, fmt.Errorf("cycle of %s: %s", extendsKey, strings.Join(append(extending, name), " -> "))
+		}
+	}
+	def, ok := defs[name]
+	if !ok {
+		return nil, fmt.Errorf("%s: undefined pattern %q", extendsKey, name)
+	}
+	return resolveExtends(def, defs, append(extending, name))
+}
+
+func extendsNames(extends interface{}) ([]string, error) {
+	switch v := extends.(type) {
+	case string:
+		return []string{v}, nil
+	case []interface{}:
+		names := make([]string, 0, len(v))
+		for _, item := range v {
+			name, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s must be a name or an array of names, got %s", extendsKey,
+					formatValue(extends))
+			}
+			names = append(names, name)
+		}
+		return names, nil
+	}
+	return nil, fmt.Errorf("%s must be a name or an array of names, got %s", extendsKey, formatValue(extends))
+}
+
+// resolveAllExtends resolves `#extends` in `spec` and in all the definitions.
+func resolveAllExtends(spec interface{}, defs map[string]interface{}) (interface{}, error) {
+	resolvedDefs := make(map[string]interface{}, len(defs))
+	for _, name := range sortedMapKeys(defs) {
+		resolved, err := resolveDef(name, defs, nil)
+		if err != nil {
+			return nil, fmt.Errorf("in definition %q: %w", name, err)
+		}
+		resolvedDefs[name] = resolved
+	}
+	for name, def := range resolvedDefs {
+		defs[name] = def
+	}
+	return resolveExtends(spec, defs, nil)
+}
@@ -0,0 +1,180 @@
+package matcher_test
+
+import (
+	"strings"
+	"testing"
+
+	matcher "github.com/panta/go-json-matcher"
+)
+
+const mergeTestArticle = `{
+  "id": "#uuid",
+  "title": "#string",
+  "publish": "#boolean",
+  "published_at": "#datetime",
+  "tags": [ "#array-of", "#string" ],
+  "author": { "id": "#number", "name": "#string", "email": "#email" }
+}`
+
+func TestMerge(t *testing.T) {
+	base := matcher.MustCompile(mergeTestArticle)
+	overlay := matcher.MustCompile(`{
+  "publish": false,
+  "published_at": "#notpresent",
+  "tags": [ "draft" ],
+  "author": { "email": "#delete", "nickname": "#string" },
+  "revision": { "number": "#integer", "note": "#delete" }
+}`, matcher.AsOverlay())
+	merged := matcher.Merge(base, overlay)
+
+	tests := []struct {
+		name string
+		j    string
+		want bool
+	}{
+		{name: "match", j: `{
+  "id": "5f0c3b9e-1a2b-4c3d-8e4f-5a6b7c8d9e0f", "title": "t", "publish": false, "tags": [ "draft" ],
+  "author": { "id": 1, "name": "joe", "email": "not an email", "nickname": "j" },
+  "revision": { "number": 2 }
+}`, want: true},
+		{name: "overridden-value", j: `{
+  "id": "5f0c3b9e-1a2b-4c3d-8e4f-5a6b7c8d9e0f", "title": "t", "publish": true, "tags": [ "draft" ],
+  "author": { "id": 1, "name": "joe", "nickname": "j" }, "revision": { "number": 2 }
+}`, want: false},
+		{name: "overridden-notpresent", j: `{
+  "id": "5f0c3b9e-1a2b-4c3d-8e4f-5a6b7c8d9e0f", "title": "t", "publish": false, "tags": [ "draft" ],
+  "published_at": "2024-05-01T12:00:00Z",
+  "author": { "id": 1, "name": "joe", "nickname": "j" }, "revision": { "number": 2 }
+}`, want: false},
+		{name: "array-replaced", j: `{
+  "id": "5f0c3b9e-1a2b-4c3d-8e4f-5a6b7c8d9e0f", "title": "t", "publish": false, "tags": [ "a", "b" ],
+  "author": { "id": 1, "name": "joe", "nickname": "j" }, "revision": { "number": 2 }
+}`, want: false},
+		{name: "base-kept", j: `{
+  "id": "42", "title": "t", "publish": false, "tags": [ "draft" ],
+  "author": { "id": 1, "name": "joe", "nickname": "j" }, "revision": { "number": 2 }
+}`, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := merged.Matches([]byte(tt.j))
+			if err != nil {
+				t.Fatalf("Matches() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Matches() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// the base pattern is unchanged
+	ok, err := base.Matches([]byte(`{
+  "id": "5f0c3b9e-1a2b-4c3d-8e4f-5a6b7c8d9e0f", "title": "t", "publish": true, "tags": [],
+  "published_at": "2024-05-01T12:00:00Z", "author": { "id": 1, "name": "joe", "email": "joe@example.com" }
+}`))
+	if err != nil || !ok {
+		t.Errorf("Matches() got = %v, %v, want true, nil", ok, err)
+	}
+}
+
+func TestMergeOverNonObject(t *testing.T) {
+	merged := matcher.Merge(matcher.MustCompile(`{ "a": "#string" }`),
+		matcher.MustCompile(`{ "a": { "x": "#delete", "y": 1 } }`, matcher.AsOverlay()))
+	ok, err := merged.Matches([]byte(`{ "a": { "y": 1 } }`))
+	if err != nil || !ok {
+		t.Errorf("Matches() got = %v, %v, want true, nil", ok, err)
+	}
+}
+
+func TestMergeWhere(t *testing.T) {
+	base := matcher.MustCompile(`{ "a": "#number", "b": "#number", "#where": "a < b" }`)
+	overlay := matcher.MustCompile(`{ "c": "#number", "#where": [ "b < c", "a < b" ] }`)
+	merged := matcher.Merge(base, overlay)
+	tests := []struct {
+		name       string
+		j          string
+		wantReason []string
+	}{
+		{name: "match", j: `{ "a": 1, "b": 2, "c": 3 }`},
+		{name: "base", j: `{ "a": 2, "b": 1, "c": 3 }`, wantReason: []string{`#where "a < b": is false (2 < 1)`}},
+		{name: "overlay", j: `{ "a": 1, "b": 3, "c": 2 }`, wantReason: []string{`#where "b < c": is false (3 < 2)`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := merged.Match([]byte(tt.j))
+			if err != nil {
+				t.Fatalf("Match() error = %v", err)
+			}
+			got := []string{}
+			for _, m := range report.Mismatches {
+				got = append(got, m.Reason)
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.wantReason, "\n") {
+				t.Errorf("Match() got = %q, want %q", got, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestOverlay(t *testing.T) {
+	overlay := matcher.MustCompile(`{ "a": "#delete", "b": { "c": "#delete", "d": 1 } }`, matcher.AsOverlay())
+	ok, err := overlay.Matches([]byte(`{ "a": "anything", "b": { "d": 1 } }`))
+	if err != nil || !ok {
+		t.Errorf("Matches() got = %v, %v, want true, nil", ok, err)
+	}
+	if _, err := matcher.Compile([]byte(`[ "#delete" ]`), matcher.AsOverlay()); err == nil {
+		t.Errorf("Compile() expected error for #delete outside an object")
+	}
+}
+
+func TestExtends(t *testing.T) {
+	p := matcher.MustCompile(`{
+  "#defs": {
+    "article": ` + mergeTestArticle + `,
+    "draft": { "#extends": "article", "publish": false, "published_at": "#notpresent" },
+    "timestamps": { "created": "#datetime" }
+  },
+  "data": { "#extends": [ "draft", "timestamps" ], "author": { "email": "#delete" } }
+}`)
+	ok, err := p.Matches([]byte(`{ "data": {
+  "id": "5f0c3b9e-1a2b-4c3d-8e4f-5a6b7c8d9e0f", "title": "t", "publish": false, "tags": [],
+  "author": { "id": 1, "name": "joe" }, "created": "2024-05-01T12:00:00Z"
+} }`))
+	if err != nil || !ok {
+		t.Errorf("Matches() got = %v, %v, want true, nil", ok, err)
+	}
+	ok, err = p.Matches([]byte(`{ "data": {
+  "id": "5f0c3b9e-1a2b-4c3d-8e4f-5a6b7c8d9e0f", "title": "t", "publish": true, "tags": [],
+  "author": { "id": 1, "name": "joe" }, "created": "2024-05-01T12:00:00Z"
+} }`))
+	if err != nil || ok {
+		t.Errorf("Matches() got = %v, %v, want false, nil", ok, err)
+	}
+}
+
+func TestExtendsInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		jSpec   string
+		wantErr string
+	}{
+		{name: "undefined", jSpec: `{ "#extends": "article" }`, wantErr: `#extends: undefined pattern "article"`},
+		{name: "cycle", jSpec: `{ "#defs": { "a": { "#extends": "b" }, "b": { "#extends": "a" } } }`,
+			wantErr: `cycle of #extends: a -> b -> a`},
+		{name: "not-a-name", jSpec: `{ "#extends": 1 }`, wantErr: `#extends must be a name or an array of names, got 1`},
+		{name: "delete-outside-overlay", jSpec: `{ "a": "#delete" }`,
+			wantErr: `invalid pattern at /a (line 1, column 8): #delete can only remove the keys of an overlay`},
+		{name: "delete-in-array", jSpec: `{ "#defs": { "a": {} }, "b": { "#extends": "a", "c": [ "#delete" ] } }`,
+			wantErr: `invalid pattern at /b/c/0`},
+		{name: "delete-as-key-pattern", jSpec: `{ "#defs": { "a": {} }, "b": { "#extends": "a", "#key #delete": 1 } }`,
+			wantErr: `unsupported pattern '#delete'`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := matcher.Compile([]byte(tt.jSpec))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Compile() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
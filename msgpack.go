@@ -0,0 +1,44 @@
+package matcher
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// MsgPackMatches checks if the MessagePack document in `doc` satisfies the
+// JSON pattern in `jPatternSpecifier`.
+// Integers keep their integer type, binary values are matched by `#bytes`
+// or by a literal string containing their base64 encoding, and timestamps
+// are compared as RFC3339 strings in UTC.
+func MsgPackMatches(doc []byte, jPatternSpecifier []byte) (bool, error) {
+	p, err := Compile(jPatternSpecifier)
+	if err != nil {
+		return false, err
+	}
+	report, err := p.MatchMsgPack(doc)
+	if err != nil {
+		return false, err
+	}
+	return report.Matches(), nil
+}
+
+// MatchMsgPack checks the MessagePack document in `doc` against the pattern
+// and returns a report listing every mismatch found.
+func (p *Pattern) MatchMsgPack(doc []byte) (*Report, error) {
+	dec := msgpack.NewDecoder(bytes.NewReader(doc))
+	// decode maps with any key type, not only strings
+	dec.SetMapDecoder(func(d *msgpack.Decoder) (interface{}, error) {
+		return d.DecodeUntypedMap()
+	})
+	x, err := dec.DecodeInterface()
+	if err != nil {
+		return nil, withSentinel(ErrInvalidDocument, fmt.Errorf("can't unmarshal left argument: %w", err))
+	}
+	x, err = normalizeDecoded(x)
+	if err != nil {
+		return nil, withSentinel(ErrInvalidDocument, fmt.Errorf("can't unmarshal left argument: %w", err))
+	}
+	return p.matchValue(x)
+}
@@ -0,0 +1,63 @@
+package matcher_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+
+	matcher "github.com/panta/go-json-matcher"
+)
+
+func TestMsgPackMatches(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     interface{}
+		jSpec   string
+		want    bool
+		wantErr bool
+	}{
+		{name: "int8", doc: int8(-5), jSpec: `-5`, want: true},
+		{name: "uint16", doc: uint16(500), jSpec: `"#integer"`, want: true},
+		{name: "int-not-float", doc: 42, jSpec: `"#float"`, want: false},
+		{name: "float32", doc: float32(0.25), jSpec: `0.25`, want: true},
+		{name: "float64-float", doc: 1.0, jSpec: `"#float"`, want: true},
+		{name: "bin", doc: []byte("hello"), jSpec: `"#bytes"`, want: true},
+		{name: "bin-base64", doc: []byte("hello"), jSpec: `"aGVsbG8="`, want: true},
+		{name: "str-not-bytes", doc: "hello", jSpec: `"#bytes"`, want: false},
+		{name: "timestamp", doc: time.Date(2022, 7, 20, 9, 56, 29, 0, time.UTC),
+			jSpec: `"2022-07-20T09:56:29Z"`, want: true},
+		{name: "map-non-string-keys", doc: map[int]string{200: "ok"}, jSpec: `{ "200": "ok" }`, want: true},
+		{name: "nested", doc: map[string]interface{}{
+			"id":   uint32(7),
+			"blob": []byte{1, 2, 3},
+			"tags": []string{"a", "b"},
+		}, jSpec: `{ "id": 7, "blob": "AQID", "tags": [ "#array-of", "#string" ] }`, want: true},
+		{name: "nested-ne", doc: map[string]interface{}{
+			"id": uint32(7),
+		}, jSpec: `{ "id": "#string" }`, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := msgpack.Marshal(tt.doc)
+			if err != nil {
+				t.Fatalf("msgpack.Marshal() error = %v", err)
+			}
+			got, err := matcher.MsgPackMatches(doc, []byte(tt.jSpec))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("MsgPackMatches() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("MsgPackMatches() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMsgPackMatchesInvalid(t *testing.T) {
+	_, err := matcher.MsgPackMatches([]byte{0xc1}, []byte(`"#ignore"`))
+	if err == nil {
+		t.Errorf("MsgPackMatches() expected error for invalid document")
+	}
+}
@@ -0,0 +1,109 @@
+package matcher
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// normalizeDecoded converts a value decoded from a binary format (CBOR,
+// MessagePack) to the value model used for JSON documents, with a few
+// differences preserving information these formats carry:
+//   - integers are kept as int64 (or uint64 when they don't fit), floats
+//     become float64;
+//   - byte strings are kept as []byte;
+//   - timestamps become RFC3339 strings in UTC;
+//   - map keys are converted to strings.
+func normalizeDecoded(x interface{}) (interface{}, error) {
+	switch v := x.(type) {
+	case nil, bool, string, int64, uint64, float64, []byte:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano), nil
+	case big.Int:
+		return normalizeBigInt(&v), nil
+	case *big.Int:
+		return normalizeBigInt(v), nil
+	case cbor.Tag:
+		// unknown tags: only their content is relevant
+		return normalizeDecoded(v.Content)
+	case []interface{}:
+		items := make([]interface{}, 0, len(v))
+		for i, item := range v {
+			normalized, err := normalizeDecoded(item)
+			if err != nil {
+				return nil, fmt.Errorf("can't normalize array element %d: %w", i, err)
+			}
+			items = append(items, normalized)
+		}
+		return items, nil
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			normalized, err := normalizeDecoded(value)
+			if err != nil {
+				return nil, fmt.Errorf("can't normalize map element %v: %w", key, err)
+			}
+			m[key] = normalized
+		}
+		return m, nil
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			keyString, err := normalizeMapKey(key)
+			if err != nil {
+				return nil, err
+			}
+			normalized, err := normalizeDecoded(value)
+			if err != nil {
+				return nil, fmt.Errorf("can't normalize map element %v: %w", keyString, err)
+			}
+			m[keyString] = normalized
+		}
+		return m, nil
+	}
+
+	xV := reflect.ValueOf(x)
+	switch {
+	case isIntegerKind(xV.Kind()) && xV.CanInt():
+		return xV.Int(), nil
+	case isIntegerKind(xV.Kind()):
+		return xV.Uint(), nil
+	}
+	return nil, fmt.Errorf("unsupported value %v (type: %T)", x, x)
+}
+
+// normalizeBigInt converts big integers (CBOR bignums) to int64 or uint64
+// when possible, and to float64 otherwise, as JSON decoding would do.
+func normalizeBigInt(v *big.Int) interface{} {
+	switch {
+	case v.IsInt64():
+		return v.Int64()
+	case v.IsUint64():
+		return v.Uint64()
+	}
+	f, _ := new(big.Float).SetInt(v).Float64()
+	return f
+}
+
+func normalizeMapKey(key interface{}) (string, error) {
+	normalized, err := normalizeDecoded(key)
+	if err != nil {
+		return "", fmt.Errorf("can't normalize map key %v: %w", key, err)
+	}
+	switch k := normalized.(type) {
+	case string:
+		return k, nil
+	case int64:
+		return strconv.FormatInt(k, 10), nil
+	case uint64:
+		return strconv.FormatUint(k, 10), nil
+	}
+	return formatValue(normalized), nil
+}
@@ -0,0 +1,141 @@
+package matcher
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// Option configures a Pattern, see Compile.
+type Option func(*options)
+
+type options struct {
+	clock        func() time.Time
+	markerPrefix string
+	library      *PatternLibrary
+	overlay      bool
+}
+
+func newOptions(opts []Option) (options, error) {
+	o := options{clock: time.Now, markerPrefix: markerPrefix}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.markerPrefix == "" {
+		return options{}, errors.New("the marker prefix can't be empty")
+	}
+	return o, nil
+}
+
+// WithClock sets the function used by the relative time markers
+// (`#datetime-within`, `#datetime-near`, `#before` and `#after`) to get the
+// current time, which by default is time.Now. The clock is read once at the
+// start of every match, so all the markers in a pattern refer to the same
+// instant. A nil clock restores time.Now.
+// A fixed clock makes tests deterministic.
+func WithClock(clock func() time.Time) Option {
+	return func(o *options) {
+		if clock == nil {
+			clock = time.Now
+		}
+		o.clock = clock
+	}
+}
+
+// WithMarkerPrefix replaces `#` with `prefix` as the start of markers (and
+// of the `#where`, `#properties`, `#key`, `#defs` and `#extends` object
+// pattern keys), for documents where strings starting with `#` are common.
+// For instance, with the prefix `$`, the pattern `"$uuid"` matches a UUID,
+// while `"#general"` matches the literal string (and `#`-prefixed object keys
+// are literal keys). Doubling the prefix escapes it: `"$$var"` matches the
+// literal `"$var"`.
+func WithMarkerPrefix(prefix string) Option {
+	return func(o *options) {
+		o.markerPrefix = prefix
+	}
+}
+
+// AsOverlay marks the pattern as an overlay for Merge, whose object keys can
+// have the value `#delete` to remove them from the base pattern. Elsewhere,
+// `#delete` is only allowed in objects with an `#extends` key. When an
+// overlay is matched directly, the keys marked with `#delete` are ignored.
+func AsOverlay() Option {
+	return func(o *options) {
+		o.overlay = true
+	}
+}
+
+// canonicalizeMarkers rewrites a pattern written with a custom marker prefix
+// to the canonical form using `#`. The values of `#enum` patterns and
+// `#where` expressions are left untouched, since they are never markers.
+func canonicalizeMarkers(spec interface{}, prefix string) interface{} {
+	if prefix == markerPrefix {
+		return spec
+	}
+	switch v := spec.(type) {
+	case string:
+		return canonicalizeMarkerString(v, prefix)
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		copy(items, v)
+		if len(items) > 0 {
+			items[0] = canonicalizeMarkers(items[0], prefix)
+			if isMarker(items[0], enumMarker) || isMarker(items[0], enumMarkerI) {
+				return items
+			}
+		}
+		for i := 1; i < len(items); i++ {
+			items[i] = canonicalizeMarkers(items[i], prefix)
+		}
+		return items
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			switch {
+			case key == prefix+whereKey[len(markerPrefix):]:
+				m[whereKey] = value
+			case key == prefix+propertiesKey[len(markerPrefix):]:
+				m[propertiesKey] = value
+			case key == prefix+extendsKey[len(markerPrefix):]:
+				m[extendsKey] = value
+			case key == prefix+defsKey[len(markerPrefix):]:
+				m[defsKey] = canonicalizeMarkers(value, prefix)
+			case strings.HasPrefix(key, prefix+keyMarker[len(markerPrefix):]+" "):
+				keyPattern := key[len(prefix+keyMarker[len(markerPrefix):]+" "):]
+				m[keyMarker+" "+canonicalizeMarkerString(keyPattern, prefix)] = canonicalizeMarkers(value, prefix)
+			default:
+				m[canonicalizeKey(key, prefix)] = canonicalizeMarkers(value, prefix)
+			}
+		}
+		return m
+	}
+	return spec
+}
+
+// canonicalizeKey rewrites a literal object pattern key written with a
+// custom marker prefix: keys starting with `#` are escaped, so that they
+// aren't taken for `#where`, `#properties` or `#key` keys.
+func canonicalizeKey(key string, prefix string) string {
+	if strings.HasPrefix(key, prefix+prefix) {
+		// escaped literal
+		key = key[len(prefix):]
+	}
+	if strings.HasPrefix(key, markerPrefix) {
+		return markerPrefix + key
+	}
+	return key
+}
+
+func canonicalizeMarkerString(s string, prefix string) string {
+	switch {
+	case strings.HasPrefix(s, prefix+prefix):
+		// escaped literal
+		s = s[len(prefix):]
+	case strings.HasPrefix(s, prefix):
+		return markerPrefix + s[len(prefix):]
+	}
+	if strings.HasPrefix(s, markerPrefix) {
+		return markerPrefix + s
+	}
+	return s
+}
@@ -0,0 +1,53 @@
+package matcher_test
+
+import (
+	"testing"
+
+	matcher "github.com/panta/go-json-matcher"
+)
+
+func TestWithMarkerPrefix(t *testing.T) {
+	tests := []struct {
+		name  string
+		j     string
+		jSpec string
+		want  bool
+	}{
+		{name: "marker", j: `"5f0c3b9e-1a2b-4c3d-8e4f-5a6b7c8d9e0f"`, jSpec: `"$uuid-v4"`, want: true},
+		{name: "marker-fail", j: `42`, jSpec: `"$string"`, want: false},
+		{name: "hash-literal", j: `{ "channel": "#general" }`, jSpec: `{ "channel": "#general" }`, want: true},
+		{name: "hash-literal-fail", j: `{ "channel": "#random" }`, jSpec: `{ "channel": "#general" }`, want: false},
+		{name: "escaped", j: `"$HOME"`, jSpec: `"$$HOME"`, want: true},
+		{name: "combinator", j: `[ "#a", "#b" ]`, jSpec: `[ "$array-of", "$starts-with #" ]`, want: true},
+		{name: "enum", j: `"#ff0000"`, jSpec: `[ "$enum", "#ff0000", "#00ff00" ]`, want: true},
+		{name: "where", j: `{ "min": 1, "max": 2 }`, jSpec: `{ "$where": "min < max" }`, want: true},
+		{name: "key-pattern", j: `{ "#a": 1, "b": "x" }`, jSpec: `{ "$key $starts-with #": "$number", "$properties": "2" }`,
+			want: true},
+		{name: "where-fail", j: `{ "min": 3, "max": 2 }`, jSpec: `{ "$where": "min < max" }`, want: false},
+		{name: "hash-where-key", j: `{ "#where": "x" }`, jSpec: `{ "#where": "x" }`, want: true},
+		{name: "hash-properties-key", j: `{ "#properties": "abc" }`, jSpec: `{ "#properties": "abc" }`, want: true},
+		{name: "hash-key-pattern-key", j: `{ "#key $string": 1, "a": "b" }`, jSpec: `{ "#key $string": 1 }`,
+			want: true},
+		{name: "hash-literal-key-fail", j: `{ "#where": "y" }`, jSpec: `{ "#where": "x" }`, want: false},
+		{name: "escaped-key", j: `{ "$where": 1 }`, jSpec: `{ "$$where": 1 }`, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := matcher.Compile([]byte(tt.jSpec), matcher.WithMarkerPrefix("$"))
+			if err != nil {
+				t.Fatalf("Compile() error = %v", err)
+			}
+			got, err := p.Matches([]byte(tt.j))
+			if err != nil {
+				t.Fatalf("Matches() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Matches() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := matcher.Compile([]byte(`"x"`), matcher.WithMarkerPrefix("")); err == nil {
+		t.Errorf("Compile() expected an error with an empty marker prefix")
+	}
+}
@@ -0,0 +1,138 @@
+package matcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Pattern is a parsed pattern specifier, ready to be matched against any
+// number of documents.
+// A Pattern is safe for concurrent use by multiple goroutines.
+type Pattern struct {
+	spec  interface{}
+	defs  map[string]interface{} // the named patterns `#use` can refer to
+	opts  options
+	where map[string]whereNode // the parsed `#where` expressions, by source
+}
+
+// makePattern returns the Pattern for the (valid) `spec` and `defs`, parsing
+// its `#where` expressions once.
+func makePattern(spec interface{}, defs map[string]interface{}, opts options) *Pattern {
+	where := map[string]whereNode{}
+	parseWhereExprs(spec, where)
+	for _, def := range defs {
+		parseWhereExprs(def, where)
+	}
+	return &Pattern{spec: spec, defs: defs, opts: opts, where: where}
+}
+
+// Compile parses the JSON pattern specifier in `jPatternSpecifier` and
+// returns a Pattern which can be used to check documents without parsing
+// the pattern again. The behaviour of the Pattern can be customised with
+// options, e.g. WithClock, WithMarkerPrefix or WithLibrary.
+// Malformed patterns are reported with a *PatternError.
+func Compile(jPatternSpecifier []byte, opts ...Option) (*Pattern, error) {
+	var patternSpecAny interface{}
+	err := json.Unmarshal(jPatternSpecifier, &patternSpecAny)
+	if err != nil {
+		return nil, withSentinel(ErrInvalidPattern, fmt.Errorf("can't unmarshal pattern argument: %w", err))
+	}
+	p, err := newPattern(patternSpecAny, opts)
+	if err != nil {
+		return nil, locatePatternError(err, func() map[string]sourcePosition {
+			// JSON is a subset of the relaxed dialect
+			_, positions, _ := unmarshalRelaxed(jPatternSpecifier)
+			return positions
+		})
+	}
+	return p, nil
+}
+
+func newPattern(spec interface{}, opts []Option) (*Pattern, error) {
+	o, err := newOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	return compileSpec(canonicalizeMarkers(spec, o.markerPrefix), o)
+}
+
+// compileSpec returns the Pattern for `spec`, whose markers have the
+// canonical prefix.
+func compileSpec(spec interface{}, o options) (*Pattern, error) {
+	if err := (validator{overlay: o.overlay}).validate(spec, ""); err != nil {
+		return nil, err
+	}
+	spec, defs, err := extractDefs(spec, o.library)
+	if err != nil {
+		return nil, withSentinel(ErrInvalidPattern, err)
+	}
+	spec, err = resolveAllExtends(spec, defs)
+	if err != nil {
+		return nil, withSentinel(ErrInvalidPattern, err)
+	}
+	if err := checkDefs(spec, defs); err != nil {
+		return nil, withSentinel(ErrInvalidPattern, err)
+	}
+	return makePattern(spec, defs, o), nil
+}
+
+// MustCompile is like Compile but panics if the pattern can't be parsed.
+// It simplifies the initialization of global variables and test tables.
+func MustCompile(jPatternSpecifier string, opts ...Option) *Pattern {
+	p, err := Compile([]byte(jPatternSpecifier), opts...)
+	if err != nil {
+		panic(`matcher: Compile(` + jPatternSpecifier + `): ` + err.Error())
+	}
+	return p
+}
+
+// Match checks the JSON document in `j` against the pattern and returns a
+// report listing every mismatch found.
+// An error is returned if `j` isn't valid JSON or the pattern is malformed.
+func (p *Pattern) Match(j []byte) (*Report, error) {
+	var jAny interface{}
+	err := json.Unmarshal(j, &jAny)
+	if err != nil {
+		return nil, withSentinel(ErrInvalidDocument, fmt.Errorf("can't unmarshal left argument: %w", err))
+	}
+	return p.matchValue(jAny)
+}
+
+// Matches checks if the JSON document in `j` satisfies the pattern.
+func (p *Pattern) Matches(j []byte) (bool, error) {
+	report, err := p.Match(j)
+	if err != nil {
+		return false, err
+	}
+	return report.Matches(), nil
+}
+
+// Check checks the JSON document in `j` against the pattern, like Match, but
+// returns the mismatches as a *MismatchError, or nil if the document matches.
+// Other errors match ErrInvalidDocument or ErrInvalidPattern.
+func (p *Pattern) Check(j []byte) error {
+	report, err := p.Match(j)
+	if err != nil {
+		return err
+	}
+	return report.Err()
+}
+
+func (p *Pattern) matchValue(x interface{}) (*Report, error) {
+	st := newMatchState(p.opts.clock(), x, p.defs)
+	st.where = p.where
+	matches, err := _match(st, x, p.spec)
+	if err != nil {
+		return nil, err
+	}
+	if !matches && len(*st.mismatches) == 0 {
+		// defensive programming: never report a failed match as an empty report
+		st.mismatch("value doesn't match the pattern")
+	}
+	// sort the mismatches by path, so that reports are the same across runs
+	sort.SliceStable(*st.mismatches, func(i, j int) bool {
+		return (*st.mismatches)[i].Path < (*st.mismatches)[j].Path
+	})
+	return &Report{Mismatches: *st.mismatches, doc: x, spec: p.spec, defs: p.defs}, nil
+}
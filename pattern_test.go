@@ -0,0 +1,181 @@
+package matcher_test
+
+import (
+	"reflect"
+	"testing"
+
+	matcher "github.com/panta/go-json-matcher"
+)
+
+func TestPatternMatch(t *testing.T) {
+	type args struct {
+		j     string
+		jSpec string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    []matcher.Mismatch
+		wantErr bool
+	}{
+		{name: "match", args: args{
+			j:     `{ "id": 1, "tags": [ "a", "b" ] }`,
+			jSpec: `{ "id": "#number", "tags": [ "#array-of", "#string" ] }`,
+		}, want: nil},
+		{name: "root-primitive", args: args{
+			j:     `42`,
+			jSpec: `43`,
+		}, want: []matcher.Mismatch{
+			{Path: "", Reason: "expected 43, got 42"},
+		}},
+		{name: "root-marker", args: args{
+			j:     `"hello"`,
+			jSpec: `"#number"`,
+		}, want: []matcher.Mismatch{
+			{Path: "", Reason: `"hello" doesn't match "#number"`},
+		}},
+		{name: "nested", args: args{
+			j:     `{ "user": { "name": "joe", "roles": [ "admin", 5 ] } }`,
+			jSpec: `{ "user": { "name": "joe", "roles": [ "#array-of", "#string" ] } }`,
+		}, want: []matcher.Mismatch{
+			{Path: "/user/roles/1", Reason: `5 doesn't match "#string"`},
+		}},
+		{name: "missing-key", args: args{
+			j:     `{}`,
+			jSpec: `{ "id": "#uuid" }`,
+		}, want: []matcher.Mismatch{
+			{Path: "/id", Reason: `missing key, expected "#uuid"`},
+		}},
+		{name: "missing-present-key", args: args{
+			j:     `{}`,
+			jSpec: `{ "id": "#present" }`,
+		}, want: []matcher.Mismatch{
+			{Path: "/id", Reason: "missing key"},
+		}},
+		{name: "unexpected-key", args: args{
+			j:     `{ "error": "boom" }`,
+			jSpec: `{ "error": "#notpresent" }`,
+		}, want: []matcher.Mismatch{
+			{Path: "/error", Reason: `unexpected key, got "boom"`},
+		}},
+		{name: "enum", args: args{
+			j:     `{ "status": "deleted", "code": 3 }`,
+			jSpec: `{ "status": "#enum draft|published", "code": [ "#enum", 1, 2, null ] }`,
+		}, want: []matcher.Mismatch{
+			{Path: "/code", Reason: `expected one of 1, 2, null, got 3`},
+			{Path: "/status", Reason: `expected one of "draft", "published", got "deleted"`},
+		}},
+		{name: "object-of", args: args{
+			j:     `{ "a": 1, "B": 2, "c": "3" }`,
+			jSpec: `[ "#object-of", "#regex ^[a-z]$", "#number", "..2" ]`,
+		}, want: []matcher.Mismatch{
+			{Path: "", Reason: `expected ..2 properties, got 3`},
+			{Path: "/B", Reason: `key "B" doesn't match "#regex ^[a-z]$"`},
+			{Path: "/c", Reason: `"3" doesn't match "#number"`},
+		}},
+		{name: "key-pattern", args: args{
+			j:     `{ "x-a": "a", "x-b": 2, "c": 3 }`,
+			jSpec: `{ "#key #starts-with x-": "#string" }`,
+		}, want: []matcher.Mismatch{
+			{Path: "/x-b", Reason: `2 doesn't match "#string"`},
+		}},
+		{name: "escaped-pointer", args: args{
+			j:     `{ "a/b": { "c~d": true } }`,
+			jSpec: `{ "a/b": { "c~d": false } }`,
+		}, want: []matcher.Mismatch{
+			{Path: "/a~1b/c~0d", Reason: "expected false, got true"},
+		}},
+		{name: "array-length", args: args{
+			j:     `[ 1, 2, 3 ]`,
+			jSpec: `[ 1, 2 ]`,
+		}, want: []matcher.Mismatch{
+			{Path: "", Reason: "expected array of length 2, got length 3"},
+		}},
+		{name: "null", args: args{
+			j:     `{ "a": null, "b": 1 }`,
+			jSpec: `{ "a": "x", "b": null }`,
+		}, want: []matcher.Mismatch{
+			{Path: "/a", Reason: `expected "x", got null`},
+			{Path: "/b", Reason: "expected null, got 1"},
+		}},
+		{name: "embedded-json", args: args{
+			j:     `{ "payload": "{\"id\": \"42\"}" }`,
+			jSpec: `{ "payload": [ "#json", { "id": "#number" } ] }`,
+		}, want: []matcher.Mismatch{
+			{Path: "/payload/id", Reason: `"42" doesn't match "#number"`},
+		}},
+		{name: "embedded-json-invalid", args: args{
+			j:     `{ "payload": "{id: 42}" }`,
+			jSpec: `{ "payload": [ "#json", "#object" ] }`,
+		}, want: []matcher.Mismatch{
+			{Path: "/payload", Reason: `expected a string containing JSON, got "{id: 42}"`},
+		}},
+		{name: "unbound-parameter", args: args{
+			j:     `"hello"`,
+			jSpec: `"#param greeting"`,
+		}, wantErr: true},
+		{name: "bad-json", args: args{
+			j:     `[A237`,
+			jSpec: `"#ignore"`,
+		}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := matcher.MustCompile(tt.args.jSpec)
+			got, err := p.Match([]byte(tt.args.j))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Match() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Matches() != (len(tt.want) == 0) {
+				t.Errorf("Match() matches = %v, want %v", got.Matches(), len(tt.want) == 0)
+			}
+			if len(got.Mismatches) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got.Mismatches, tt.want) {
+				t.Errorf("Match() mismatches = %v, want %v", got.Mismatches, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileInvalid(t *testing.T) {
+	_, err := matcher.Compile([]byte(`{ "id": `))
+	if err == nil {
+		t.Errorf("Compile() expected error for invalid JSON")
+	}
+}
+
+func TestMatchDeterministic(t *testing.T) {
+	p := matcher.MustCompile(`{ "a": 1, "b": { "c": "#string", "d": [ 1, 2 ] }, "e": "#uuid", "f": true, "g": null }`)
+	j := []byte(`{ "a": 2, "b": { "c": 3, "d": [ 2, 1 ] }, "e": "x", "f": false, "g": 0 }`)
+	want := ""
+	for i := 0; i < 50; i++ {
+		report, err := p.Match(j)
+		if err != nil {
+			t.Fatalf("Match() error = %v", err)
+		}
+		if i == 0 {
+			want = report.String()
+			continue
+		}
+		if got := report.String(); got != want {
+			t.Fatalf("Match() report =\n%s\nwant =\n%s", got, want)
+		}
+	}
+}
+
+func TestReportString(t *testing.T) {
+	r := matcher.Report{Mismatches: []matcher.Mismatch{
+		{Path: "", Reason: "first"},
+		{Path: "/a/0", Reason: "second"},
+	}}
+	want := "/: first\n/a/0: second"
+	if got := r.String(); got != want {
+		t.Errorf("String() got = %q, want %q", got, want)
+	}
+}
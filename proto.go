@@ -0,0 +1,57 @@
+package matcher
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// ProtoOptions configures how a protobuf message is converted to JSON
+// before being matched.
+type ProtoOptions struct {
+	// EmitUnpopulated emits unpopulated fields with their zero value, so
+	// that they can be matched (by default they're not present).
+	EmitUnpopulated bool
+	// UseProtoNames uses the field names of the .proto file (e.g.
+	// `created_at`) instead of the lowerCamelCase JSON names (`createdAt`).
+	UseProtoNames bool
+}
+
+// ProtoMatches checks if the protobuf message `m` satisfies the JSON pattern
+// in `jPatternSpecifier`.
+// The message is converted to JSON using its canonical protojson mapping,
+// so the pattern must follow that mapping: for instance 64-bit integers
+// and byte fields are strings, `google.protobuf.Timestamp` values are
+// RFC3339 strings (matched by `#datetime`) and `google.protobuf.Duration`
+// values are strings like "1.5s" (matched by `#duration`).
+func ProtoMatches(m proto.Message, jPatternSpecifier []byte, opts ProtoOptions) (bool, error) {
+	p, err := Compile(jPatternSpecifier)
+	if err != nil {
+		return false, err
+	}
+	report, err := p.MatchProto(m, opts)
+	if err != nil {
+		return false, err
+	}
+	return report.Matches(), nil
+}
+
+// MatchProto checks the protobuf message `m` against the pattern and
+// returns a report listing every mismatch found.
+func (p *Pattern) MatchProto(m proto.Message, opts ProtoOptions) (*Report, error) {
+	j, err := protojson.MarshalOptions{
+		EmitUnpopulated: opts.EmitUnpopulated,
+		UseProtoNames:   opts.UseProtoNames,
+	}.Marshal(m)
+	if err != nil {
+		return nil, withSentinel(ErrInvalidDocument, fmt.Errorf("can't convert left argument to JSON: %w", err))
+	}
+	var jAny interface{}
+	err = json.Unmarshal(j, &jAny)
+	if err != nil {
+		return nil, withSentinel(ErrInvalidDocument, fmt.Errorf("can't unmarshal left argument: %w", err))
+	}
+	return p.matchValue(jAny)
+}
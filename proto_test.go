@@ -0,0 +1,145 @@
+package matcher_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	matcher "github.com/panta/go-json-matcher"
+)
+
+// newTestJob builds a message equivalent to the one generated from:
+//
+//	message Job {
+//	  string job_id = 1;
+//	  int32 retries = 2;
+//	  int64 size_bytes = 3;
+//	  google.protobuf.Timestamp created_at = 4;
+//	  google.protobuf.Duration elapsed = 5;
+//	  repeated string tags = 6;
+//	}
+func newTestJob(t *testing.T) *dynamicpb.Message {
+	t.Helper()
+
+	field := func(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type,
+		label descriptorpb.FieldDescriptorProto_Label, typeName string,
+	) *descriptorpb.FieldDescriptorProto {
+		f := &descriptorpb.FieldDescriptorProto{
+			Name:   proto.String(name),
+			Number: proto.Int32(number),
+			Type:   typ.Enum(),
+			Label:  label.Enum(),
+		}
+		if typeName != "" {
+			f.TypeName = proto.String(typeName)
+		}
+		return f
+	}
+	optional := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+	fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:       proto.String("matcher_test/job.proto"),
+		Package:    proto.String("matcher_test"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/timestamp.proto", "google/protobuf/duration.proto"},
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Job"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				field("job_id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, optional, ""),
+				field("retries", 2, descriptorpb.FieldDescriptorProto_TYPE_INT32, optional, ""),
+				field("size_bytes", 3, descriptorpb.FieldDescriptorProto_TYPE_INT64, optional, ""),
+				field("created_at", 4, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, optional,
+					".google.protobuf.Timestamp"),
+				field("elapsed", 5, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, optional,
+					".google.protobuf.Duration"),
+				field("tags", 6, descriptorpb.FieldDescriptorProto_TYPE_STRING,
+					descriptorpb.FieldDescriptorProto_LABEL_REPEATED, ""),
+			},
+		}},
+	}, protoregistry.GlobalFiles)
+	if err != nil {
+		t.Fatalf("protodesc.NewFile() error = %v", err)
+	}
+
+	md := fd.Messages().ByName("Job")
+	m := dynamicpb.NewMessage(md)
+	fields := md.Fields()
+	m.Set(fields.ByName("job_id"), protoreflect.ValueOfString("a5bf6b35-61b2-4187-8396-463a3d6c742b"))
+	m.Set(fields.ByName("size_bytes"), protoreflect.ValueOfInt64(1024))
+	m.Set(fields.ByName("created_at"), protoreflect.ValueOfMessage(
+		timestamppb.New(time.Date(2022, 7, 20, 9, 56, 29, 500000000, time.UTC)).ProtoReflect()))
+	m.Set(fields.ByName("elapsed"), protoreflect.ValueOfMessage(
+		durationpb.New(1500*time.Millisecond).ProtoReflect()))
+	return m
+}
+
+func TestProtoMatches(t *testing.T) {
+	job := newTestJob(t)
+
+	tests := []struct {
+		name    string
+		jSpec   string
+		opts    matcher.ProtoOptions
+		want    bool
+		wantErr bool
+	}{
+		{name: "json-names", jSpec: `{
+  "jobId": "#uuid-v4",
+  "sizeBytes": "1024",
+  "createdAt": "#datetime",
+  "elapsed": "#duration",
+  "retries": "#notpresent",
+  "tags": "#notpresent"
+}`, want: true},
+		{name: "literal-well-known-types", jSpec: `{
+  "createdAt": "2022-07-20T09:56:29.500Z",
+  "elapsed": "1.500s"
+}`, want: true},
+		{name: "proto-names", jSpec: `{ "job_id": "#uuid", "size_bytes": "#string", "created_at": "#datetime" }`,
+			opts: matcher.ProtoOptions{UseProtoNames: true}, want: true},
+		{name: "proto-names-not-used", jSpec: `{ "job_id": "#uuid" }`, want: false},
+		{name: "emit-unpopulated", jSpec: `{ "retries": 0, "tags": [] }`,
+			opts: matcher.ProtoOptions{EmitUnpopulated: true}, want: true},
+		{name: "mismatch", jSpec: `{ "elapsed": "#datetime" }`, want: false},
+		{name: "bad-pattern", jSpec: `{ "jobId": `, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := matcher.ProtoMatches(job, []byte(tt.jSpec), tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ProtoMatches() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ProtoMatches() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProtoMatchesWellKnownTypes(t *testing.T) {
+	got, err := matcher.ProtoMatches(durationpb.New(90*time.Minute), []byte(`"#duration"`), matcher.ProtoOptions{})
+	if err != nil || !got {
+		t.Errorf("ProtoMatches(Duration) got = %v, error = %v, want true", got, err)
+	}
+	got, err = matcher.ProtoMatches(timestamppb.Now(), []byte(`"#datetime"`), matcher.ProtoOptions{})
+	if err != nil || !got {
+		t.Errorf("ProtoMatches(Timestamp) got = %v, error = %v, want true", got, err)
+	}
+}
+
+func TestProtoMatchesInvalidMessage(t *testing.T) {
+	// the seconds are out of the range of google.protobuf.Timestamp
+	_, err := matcher.ProtoMatches(&timestamppb.Timestamp{Seconds: -1e12}, []byte(`"#datetime"`), matcher.ProtoOptions{})
+	if !errors.Is(err, matcher.ErrInvalidDocument) {
+		t.Errorf("ProtoMatches() error = %v, want an ErrInvalidDocument", err)
+	}
+}
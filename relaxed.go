@@ -0,0 +1,438 @@
+package matcher
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// CompileRelaxed parses a pattern specifier written in a relaxed,
+// JSON5-style dialect of JSON, handy for large patterns embedded in Go
+// source. In addition to plain JSON, it accepts:
+//   - `// line` and `/* block */` comments;
+//   - trailing commas in objects and arrays;
+//   - unquoted object keys, when they are identifiers (e.g. `id`, `$ref`);
+//   - single-quoted strings, and line continuations (a backslash at the end
+//     of a line) in strings;
+//   - hexadecimal numbers, numbers with a leading `+`, or a leading or
+//     trailing decimal point.
+//
+// Syntax errors carry the line and column of the offending character.
+// The options are the same as for Compile.
+func CompileRelaxed(pattern []byte, opts ...Option) (*Pattern, error) {
+	spec, positions, err := unmarshalRelaxed(pattern)
+	if err != nil {
+		return nil, withSentinel(ErrInvalidPattern, fmt.Errorf("can't unmarshal pattern argument: %w", err))
+	}
+	p, err := newPattern(spec, opts)
+	if err != nil {
+		return nil, locatePatternError(err, func() map[string]sourcePosition { return positions })
+	}
+	return p, nil
+}
+
+// MustCompileRelaxed is like CompileRelaxed but panics if the pattern can't
+// be parsed.
+func MustCompileRelaxed(pattern string, opts ...Option) *Pattern {
+	p, err := CompileRelaxed([]byte(pattern), opts...)
+	if err != nil {
+		panic(`matcher: CompileRelaxed(` + pattern + `): ` + err.Error())
+	}
+	return p
+}
+
+// unmarshalRelaxed decodes a relaxed JSON document into the value model
+// used for JSON, returning also the position of every value indexed by its
+// JSON Pointer.
+func unmarshalRelaxed(data []byte) (interface{}, map[string]sourcePosition, error) {
+	p := relaxedParser{src: string(data), line: 1, column: 1, positions: map[string]sourcePosition{}}
+	v, err := p.parse()
+	if err != nil {
+		return nil, nil, err
+	}
+	return v, p.positions, nil
+}
+
+func (p *relaxedParser) parse() (interface{}, error) {
+	if !utf8.ValidString(p.src) {
+		return nil, p.errorf("invalid UTF-8")
+	}
+	if err := p.skipSpace(); err != nil {
+		return nil, err
+	}
+	v, err := p.parseValue("")
+	if err != nil {
+		return nil, err
+	}
+	if err := p.skipSpace(); err != nil {
+		return nil, err
+	}
+	if !p.eof() {
+		return nil, p.errorf("unexpected %s after top-level value", p.describeNext())
+	}
+	return v, nil
+}
+
+// relaxedParser is a recursive descent parser for the relaxed JSON dialect,
+// keeping track of the current line and column (in characters, 1-based).
+type relaxedParser struct {
+	src       string
+	pos       int
+	line      int
+	column    int
+	positions map[string]sourcePosition // the positions of the values parsed so far
+	// the JSON Pointers and positions of the repeated keys of objects, whose
+	// values replace the previous ones
+	duplicateKeys []duplicateKey
+}
+
+type duplicateKey struct {
+	pointer  string
+	position sourcePosition
+}
+
+// relaxedSyntaxError is a syntax error in a relaxed JSON document.
+type relaxedSyntaxError struct {
+	position sourcePosition
+	msg      string
+}
+
+func (e *relaxedSyntaxError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.position.line, e.position.column, e.msg)
+}
+
+func (p *relaxedParser) errorf(format string, args ...interface{}) error {
+	return &relaxedSyntaxError{position: sourcePosition{line: p.line, column: p.column}, msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *relaxedParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *relaxedParser) peek() rune {
+	if p.eof() {
+		return utf8.RuneError
+	}
+	r, _ := utf8.DecodeRuneInString(p.src[p.pos:])
+	return r
+}
+
+func (p *relaxedParser) next() rune {
+	r, size := utf8.DecodeRuneInString(p.src[p.pos:])
+	p.pos += size
+	if r == '\n' {
+		p.line++
+		p.column = 1
+	} else {
+		p.column++
+	}
+	return r
+}
+
+func (p *relaxedParser) describeNext() string {
+	if p.eof() {
+		return "end of input"
+	}
+	return strconv.QuoteRune(p.peek())
+}
+
+// skipSpace skips white space and comments.
+func (p *relaxedParser) skipSpace() error {
+	for !p.eof() {
+		switch {
+		case unicode.IsSpace(p.peek()) || p.peek() == '\ufeff':
+			p.next()
+		case strings.HasPrefix(p.src[p.pos:], "//"):
+			for !p.eof() && p.peek() != '\n' {
+				p.next()
+			}
+		case strings.HasPrefix(p.src[p.pos:], "/*"):
+			line, column := p.line, p.column
+			p.next()
+			p.next()
+			for !strings.HasPrefix(p.src[p.pos:], "*/") {
+				if p.eof() {
+					p.line, p.column = line, column
+					return p.errorf("unterminated comment")
+				}
+				p.next()
+			}
+			p.next()
+			p.next()
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// parseValue parses the value at `pointer` (its JSON Pointer).
+func (p *relaxedParser) parseValue(pointer string) (interface{}, error) {
+	p.positions[pointer] = sourcePosition{line: p.line, column: p.column}
+	switch r := p.peek(); {
+	case p.eof():
+		return nil, p.errorf("unexpected end of input, expected a value")
+	case r == '{':
+		return p.parseObject(pointer)
+	case r == '[':
+		return p.parseArray(pointer)
+	case r == '"' || r == '\'':
+		return p.parseString()
+	case r == '-' || r == '+' || r == '.' || (r >= '0' && r <= '9'):
+		return p.parseNumber()
+	case isIdentifierStart(r):
+		line, column := p.line, p.column
+		word := p.parseIdentifier()
+		switch word {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		p.line, p.column = line, column
+		return nil, p.errorf("unexpected %q, expected a value", word)
+	default:
+		return nil, p.errorf("unexpected %s, expected a value", p.describeNext())
+	}
+}
+
+func (p *relaxedParser) parseObject(pointer string) (interface{}, error) {
+	p.next() // {
+	m := map[string]interface{}{}
+	for {
+		if err := p.skipSpace(); err != nil {
+			return nil, err
+		}
+		if p.peek() == '}' {
+			p.next()
+			return m, nil
+		}
+
+		var key string
+		keyPosition := sourcePosition{line: p.line, column: p.column}
+		switch r := p.peek(); {
+		case r == '"' || r == '\'':
+			var err error
+			key, err = p.parseString()
+			if err != nil {
+				return nil, err
+			}
+		case isIdentifierStart(r):
+			key = p.parseIdentifier()
+		default:
+			return nil, p.errorf("unexpected %s, expected an object key", p.describeNext())
+		}
+
+		if err := p.skipSpace(); err != nil {
+			return nil, err
+		}
+		if p.peek() != ':' {
+			return nil, p.errorf("unexpected %s, expected ':'", p.describeNext())
+		}
+		p.next()
+		if err := p.skipSpace(); err != nil {
+			return nil, err
+		}
+		valuePointer := pointer + "/" + escapePointerToken(key)
+		if _, ok := m[key]; ok {
+			p.duplicateKeys = append(p.duplicateKeys, duplicateKey{pointer: valuePointer, position: keyPosition})
+		}
+		value, err := p.parseValue(valuePointer)
+		if err != nil {
+			return nil, err
+		}
+		m[key] = value
+
+		if err := p.skipSpace(); err != nil {
+			return nil, err
+		}
+		switch p.peek() {
+		case ',':
+			p.next()
+		case '}':
+		default:
+			return nil, p.errorf("unexpected %s, expected ',' or '}'", p.describeNext())
+		}
+	}
+}
+
+func (p *relaxedParser) parseArray(pointer string) (interface{}, error) {
+	p.next() // [
+	items := []interface{}{}
+	for {
+		if err := p.skipSpace(); err != nil {
+			return nil, err
+		}
+		if p.peek() == ']' {
+			p.next()
+			return items, nil
+		}
+		item, err := p.parseValue(pointer + "/" + strconv.Itoa(len(items)))
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+
+		if err := p.skipSpace(); err != nil {
+			return nil, err
+		}
+		switch p.peek() {
+		case ',':
+			p.next()
+		case ']':
+		default:
+			return nil, p.errorf("unexpected %s, expected ',' or ']'", p.describeNext())
+		}
+	}
+}
+
+func isIdentifierStart(r rune) bool {
+	return r == '_' || r == '$' || unicode.IsLetter(r)
+}
+
+func (p *relaxedParser) parseIdentifier() string {
+	start := p.pos
+	for !p.eof() {
+		r := p.peek()
+		if !isIdentifierStart(r) && !unicode.IsDigit(r) {
+			break
+		}
+		p.next()
+	}
+	return p.src[start:p.pos]
+}
+
+//nolint:gomnd // the "magic" literal constants here are the sizes of escape sequences
+func (p *relaxedParser) parseString() (string, error) {
+	quote := p.next()
+	var sb strings.Builder
+	for {
+		if p.eof() {
+			return "", p.errorf("unterminated string")
+		}
+		r := p.peek()
+		switch {
+		case r == quote:
+			p.next()
+			return sb.String(), nil
+		case r == '\n':
+			return "", p.errorf("unterminated string (use a backslash to continue it on the next line)")
+		case r < ' ':
+			return "", p.errorf("invalid control character %q in string", r)
+		case r != '\\':
+			sb.WriteRune(p.next())
+			continue
+		}
+
+		p.next() // backslash
+		if p.eof() {
+			return "", p.errorf("unterminated string")
+		}
+		escape := p.peek()
+		switch escape {
+		case '"', '\'', '\\', '/':
+			sb.WriteRune(p.next())
+		case 'b':
+			p.next()
+			sb.WriteByte('\b')
+		case 'f':
+			p.next()
+			sb.WriteByte('\f')
+		case 'n':
+			p.next()
+			sb.WriteByte('\n')
+		case 'r':
+			p.next()
+			sb.WriteByte('\r')
+		case 't':
+			p.next()
+			sb.WriteByte('\t')
+		case '\n':
+			// line continuation
+			p.next()
+		case 'x', 'u':
+			p.next()
+			size := 2
+			if escape == 'u' {
+				size = 4
+			}
+			r, err := p.parseHexRune(size)
+			if err != nil {
+				return "", err
+			}
+			if escape == 'u' && utf16.IsSurrogate(r) && strings.HasPrefix(p.src[p.pos:], `\u`) {
+				p.next()
+				p.next()
+				low, err := p.parseHexRune(4)
+				if err != nil {
+					return "", err
+				}
+				r = utf16.DecodeRune(r, low)
+			}
+			sb.WriteRune(r)
+		default:
+			return "", p.errorf("invalid escape sequence \\%c", escape)
+		}
+	}
+}
+
+func (p *relaxedParser) parseHexRune(size int) (rune, error) {
+	if len(p.src)-p.pos < size {
+		return 0, p.errorf("invalid escape sequence")
+	}
+	n, err := strconv.ParseUint(p.src[p.pos:p.pos+size], 16, 32)
+	if err != nil {
+		return 0, p.errorf("invalid escape sequence")
+	}
+	for i := 0; i < size; i++ {
+		p.next()
+	}
+	return rune(n), nil
+}
+
+func (p *relaxedParser) parseNumber() (interface{}, error) {
+	line, column := p.line, p.column
+	start := p.pos
+	for !p.eof() {
+		r := p.peek()
+		if !(r >= '0' && r <= '9') && !(r >= 'a' && r <= 'z') && !(r >= 'A' && r <= 'Z') &&
+			r != '.' && r != '+' && r != '-' {
+			break
+		}
+		p.next()
+	}
+	text := p.src[start:p.pos]
+
+	sign := 1.0
+	digits := text
+	switch {
+	case strings.HasPrefix(digits, "-"):
+		sign, digits = -1, digits[1:]
+	case strings.HasPrefix(digits, "+"):
+		digits = digits[1:]
+	}
+	var f float64
+	var err error
+	if strings.HasPrefix(digits, "0x") || strings.HasPrefix(digits, "0X") {
+		var n uint64
+		n, err = strconv.ParseUint(digits[2:], 16, 64)
+		f = float64(n)
+	} else if digits != "" && digits[0] != '+' && digits[0] != '-' && !strings.ContainsAny(digits, "xXpP_") &&
+		!strings.EqualFold(digits, "inf") && !strings.EqualFold(digits, "infinity") &&
+		!strings.EqualFold(digits, "nan") {
+		f, err = strconv.ParseFloat(digits, 64)
+	} else {
+		err = strconv.ErrSyntax
+	}
+	if err != nil || math.IsInf(f, 0) {
+		p.line, p.column = line, column
+		return nil, p.errorf("invalid number %q", text)
+	}
+	return sign * f, nil
+}
@@ -0,0 +1,71 @@
+package matcher_test
+
+import (
+	"strings"
+	"testing"
+
+	matcher "github.com/panta/go-json-matcher"
+)
+
+func TestCompileRelaxed(t *testing.T) {
+	p := matcher.MustCompileRelaxed(`
+// an article
+{
+  id: "#uuid",
+  title: '#string', /* single quotes */
+  "it's": 'it\'s',
+  "section_id": +42,
+  ratio: .5,
+  mask: 0xff,
+  tags: [ '#array-of', "#string", ],
+  author: {
+    $ref: 'a long \
+string',
+    verified: true,
+    deleted: null, // trailing comma
+  },
+  "#where": "ratio < 1",
+}
+`)
+	ok, err := p.Matches([]byte(`{
+  "id": "5f0c3b9e-1a2b-4c3d-8e4f-5a6b7c8d9e0f",
+  "title": "t",
+  "it's": "it's",
+  "section_id": 42,
+  "ratio": 0.5,
+  "mask": 255,
+  "tags": [ "a" ],
+  "author": { "$ref": "a long string", "verified": true, "deleted": null }
+}`))
+	if err != nil || !ok {
+		t.Errorf("Matches() got = %v, %v, want true, nil", ok, err)
+	}
+}
+
+func TestCompileRelaxedInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		wantErr string
+	}{
+		{name: "unquoted-value", pattern: "{\n  id: uuid\n}",
+			wantErr: `line 2, column 7: unexpected "uuid", expected a value`},
+		{name: "missing-comma", pattern: "[ 1\n  2 ]", wantErr: `line 2, column 3: unexpected '2', expected ',' or ']'`},
+		{name: "missing-colon", pattern: `{ id "x" }`, wantErr: `line 1, column 6: unexpected '"', expected ':'`},
+		{name: "unterminated-string", pattern: "{ id: 'x\n}", wantErr: `line 1, column 9: unterminated string`},
+		{name: "unterminated-comment", pattern: "{ /* x\n", wantErr: `line 1, column 3: unterminated comment`},
+		{name: "invalid-number", pattern: `{ n: 1.2.3 }`, wantErr: `line 1, column 6: invalid number "1.2.3"`},
+		{name: "infinity", pattern: `Infinity`, wantErr: `line 1, column 1: unexpected "Infinity", expected a value`},
+		{name: "invalid-escape", pattern: `"\q"`, wantErr: `line 1, column 3: invalid escape sequence \q`},
+		{name: "trailing-data", pattern: `{} {}`, wantErr: `line 1, column 4: unexpected '{' after top-level value`},
+		{name: "empty", pattern: ` // nothing`, wantErr: `line 1, column 12: unexpected end of input, expected a value`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := matcher.CompileRelaxed([]byte(tt.pattern))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("CompileRelaxed() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package matcher
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Mismatch describes a single place where a document doesn't satisfy a pattern.
+type Mismatch struct {
+	// Path is the JSON Pointer (RFC 6901) of the offending value in the document.
+	Path string
+	// Reason is a human readable explanation of the mismatch.
+	Reason string
+	// Line and Column locate the offending value (or its closest existing
+	// ancestor) in the document source, when known. They are 1-based, and
+	// zero when the position is not available.
+	Line   int
+	Column int
+}
+
+// String returns the mismatch formatted as "PATH: REASON", or
+// "PATH (line L, column C): REASON" when the position is known.
+func (m Mismatch) String() string {
+	path := m.Path
+	if path == "" {
+		path = "/"
+	}
+	if m.Line > 0 {
+		return fmt.Sprintf("%s (line %d, column %d): %s", path, m.Line, m.Column, m.Reason)
+	}
+	return fmt.Sprintf("%s: %s", path, m.Reason)
+}
+
+// Report collects the mismatches found while matching a document against
+// a pattern. An empty report means that the document matches.
+type Report struct {
+	Mismatches []Mismatch
+
+	// the document and pattern the report refers to, used to render diffs
+	doc  interface{}
+	spec interface{}
+	defs map[string]interface{}
+}
+
+// Matches returns true if no mismatch has been found.
+func (r *Report) Matches() bool {
+	return len(r.Mismatches) == 0
+}
+
+// Err returns nil if no mismatch has been found, or a *MismatchError
+// carrying the report otherwise.
+func (r *Report) Err() error {
+	if r.Matches() {
+		return nil
+	}
+	return &MismatchError{Report: r}
+}
+
+// String returns the mismatches in the report, one per line.
+func (r *Report) String() string {
+	lines := make([]string, 0, len(r.Mismatches))
+	for _, m := range r.Mismatches {
+		lines = append(lines, m.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// matchState carries the context of the value currently being matched: its
+// JSON Pointer within the document, where to collect mismatches, the
+// instant the relative time markers refer to and the document root (for
+// `#where` expressions) and the named patterns `#use` can refer to.
+type matchState struct {
+	path       string
+	mismatches *[]Mismatch
+	now        time.Time
+	root       interface{}
+	defs       map[string]interface{}
+	where      map[string]whereNode // the parsed `#where` expressions, by source
+}
+
+func newMatchState(now time.Time, root interface{}, defs map[string]interface{}) matchState {
+	return matchState{mismatches: &[]Mismatch{}, now: now, root: root, defs: defs}
+}
+
+// child returns the state for the element identified by `token` (an object
+// key or an array index) of the current value.
+func (st matchState) child(token string) matchState {
+	child := st
+	child.path = st.path + "/" + escapePointerToken(token)
+	return child
+}
+
+// probe returns a state for a trial match, whose mismatches are discarded.
+func (st matchState) probe() matchState {
+	probe := st
+	probe.mismatches = &[]Mismatch{}
+	return probe
+}
+
+// escapePointerToken escapes `token` to be used as a JSON Pointer reference token.
+func escapePointerToken(token string) string {
+	token = strings.ReplaceAll(token, "~", "~0")
+	return strings.ReplaceAll(token, "/", "~1")
+}
+
+func (st matchState) mismatch(format string, args ...interface{}) {
+	*st.mismatches = append(*st.mismatches, Mismatch{
+		Path:   st.path,
+		Reason: fmt.Sprintf(format, args...),
+	})
+}
+
+const maxFormattedValueLen = 60
+
+// formatValue returns a compact JSON-like representation of `x`, suitable
+// for mismatch reasons.
+func formatValue(x interface{}) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(x); err != nil {
+		return fmt.Sprintf("%v", x)
+	}
+	r := []rune(strings.TrimSuffix(buf.String(), "\n"))
+	if len(r) > maxFormattedValueLen {
+		return string(r[:maxFormattedValueLen-3]) + "..."
+	}
+	return string(r)
+}
@@ -0,0 +1,190 @@
+package matcher
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// validator checks the parts of a pattern which don't depend on the
+// document: marker names and arguments, the arguments of combinators, the
+// `#array-of` arity, `#where` expressions and `#properties` ranges.
+// Problems are reported as a *PatternError with the JSON Pointer of the
+// malformed value.
+type validator struct {
+	includes bool // whether `#include PATH` strings are allowed (pattern files)
+	// whether the object being validated is an overlay (see AsOverlay and
+	// `#extends`), whose keys can be removed with `#delete`
+	overlay bool
+	// whether `#delete` is allowed for the value being validated, i.e. it is
+	// the value of a key of an overlay
+	deletions bool
+	// if not nil, the problems are collected here and the validation goes on,
+	// rather than stopping at the first one
+	problems *[]*PatternError
+}
+
+// fail reports `err`, returning the error which stops the validation, if any.
+func (v validator) fail(err *PatternError) error {
+	if v.problems != nil {
+		*v.problems = append(*v.problems, err)
+		return nil
+	}
+	return err
+}
+
+func (v validator) validate(spec interface{}, pointer string) error {
+	deletions := v.deletions
+	v.deletions = false
+	switch s := spec.(type) {
+	case string:
+		if isMarker(s, deleteMarker) {
+			if deletions {
+				return nil
+			}
+			return v.fail(&PatternError{Pointer: pointer, Err: fmt.Errorf("%s can only remove the keys of an overlay, "+
+				"in an object with %s or in a pattern compiled with AsOverlay", deleteMarker, extendsKey)})
+		}
+		return v.validateMarker(s, pointer)
+	case []interface{}:
+		v.overlay = false
+		return v.validateArray(s, pointer)
+	case map[string]interface{}:
+		_, extends := s[extendsKey]
+		v.overlay = v.overlay || extends
+		for _, key := range sortedMapKeys(s) {
+			item := v
+			item.deletions = v.overlay
+			if err := item.validateKey(key, s[key], pointer+"/"+escapePointerToken(key)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// probeState returns the state used to run markers and combinators against
+// a placeholder value, to make them check their arguments.
+func probeState() matchState {
+	return newMatchState(time.Time{}, nil, nil)
+}
+
+func (v validator) validateMarker(s string, pointer string) error {
+	isMarker, marker := getMarker(s)
+	if !isMarker {
+		return nil
+	}
+	if _, isParam := getParam(marker); isParam {
+		return nil
+	}
+	if _, isUse := getUse(marker); isUse {
+		// checked by checkDefs, once all the definitions are known
+		return nil
+	}
+	if v.includes && strings.HasPrefix(marker, includeMarker+" ") {
+		return nil
+	}
+	if isEnum, name, args := getCompactEnum(marker); isEnum {
+		if _, err := combinators[name](probeState(), "", args); err != nil {
+			return v.fail(&PatternError{Pointer: pointer, Err: fmt.Errorf("invalid %s marker: %w", name, err)})
+		}
+		return nil
+	}
+	if _, err := _matchWithMarker(probeState(), "", marker); err != nil {
+		return v.fail(&PatternError{Pointer: pointer, Err: err})
+	}
+	return nil
+}
+
+func (v validator) validateArray(items []interface{}, pointer string) error {
+	if len(items) > 0 && isMarker(items[0], arrayOfMarker) {
+		//nolint:gomnd // the "magic" literal constant 2 here is clearer than a synthetic constant symbol
+		if len(items) != 2 {
+			return v.fail(&PatternError{Pointer: pointer,
+				Err: fmt.Errorf("%s expects exactly one pattern, got %d", arrayOfMarker, len(items)-1)})
+		}
+		return v.validate(items[1], pointer+"/1")
+	}
+	if isCombinator, name, args := getCombinator(items); isCombinator {
+		if _, err := combinators[name](probeState(), map[string]interface{}{}, args); err != nil {
+			return v.fail(&PatternError{Pointer: pointer, Err: fmt.Errorf("invalid %s pattern: %w", name, err)})
+		}
+		if name == enumMarker || name == enumMarkerI {
+			// the values are literals
+			return nil
+		}
+		for i, arg := range args {
+			if err := v.validate(arg, pointer+"/"+strconv.Itoa(i+1)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for i, item := range items {
+		if err := v.validate(item, pointer+"/"+strconv.Itoa(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v validator) validateKey(key string, value interface{}, pointer string) error {
+	switch key {
+	case whereKey:
+		return v.validateWhere(value, pointer)
+	case propertiesKey:
+		if _, err := _matchProperties(probeState(), map[string]interface{}{}, value); err != nil {
+			return v.fail(&PatternError{Pointer: pointer, Err: err})
+		}
+		return nil
+	case extendsKey:
+		// checked when resolving the extended patterns
+		return nil
+	}
+	if isKeyPattern, keyPattern := getKeyPattern(key); isKeyPattern {
+		if _, isParam := getParam(keyPattern); isParam {
+			// keys are never substituted by Bind
+			if err := v.fail(&PatternError{Pointer: pointer,
+				Err: fmt.Errorf("%s can't be used in %s patterns", paramMarker, keyMarker)}); err != nil {
+				return err
+			}
+		} else if err := v.validateMarker(keyPattern, pointer); err != nil {
+			return err
+		}
+	}
+	return v.validate(value, pointer)
+}
+
+func (v validator) validateWhere(spec interface{}, pointer string) error {
+	switch s := spec.(type) {
+	case string:
+		return v.validateWhereExpr(s, pointer)
+	case []interface{}:
+		for i, item := range s {
+			itemPointer := pointer + "/" + strconv.Itoa(i)
+			source, ok := item.(string)
+			if !ok {
+				if err := v.fail(&PatternError{Pointer: itemPointer,
+					Err: fmt.Errorf("%s expressions must be strings, got %s", whereKey, formatValue(item))}); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := v.validateWhereExpr(source, itemPointer); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return v.fail(&PatternError{Pointer: pointer,
+		Err: fmt.Errorf("%s must be an expression or an array of expressions, got %s", whereKey, formatValue(spec))})
+}
+
+func (v validator) validateWhereExpr(source string, pointer string) error {
+	if _, err := parseWhereExpr(source); err != nil {
+		return v.fail(&PatternError{Pointer: pointer,
+			Err: fmt.Errorf("invalid %s expression %q: %w", whereKey, source, err)})
+	}
+	return nil
+}
@@ -0,0 +1,860 @@
+package matcher
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// whereKey is the object pattern key holding cross-field constraints, as an
+// expression or an array of expressions which must all be true.
+const whereKey = "#where"
+
+// parseWhereExprs adds the parsed `#where` expressions found in `spec` to
+// `exprs`, indexed by source. Malformed expressions are skipped: they are
+// reported when validating the pattern.
+func parseWhereExprs(spec interface{}, exprs map[string]whereNode) {
+	switch v := spec.(type) {
+	case []interface{}:
+		for _, item := range v {
+			parseWhereExprs(item, exprs)
+		}
+	case map[string]interface{}:
+		for key, value := range v {
+			if key != whereKey {
+				parseWhereExprs(value, exprs)
+				continue
+			}
+			sources, _ := value.([]interface{})
+			if source, ok := value.(string); ok {
+				sources = []interface{}{source}
+			}
+			for _, item := range sources {
+				source, _ := item.(string)
+				if _, ok := exprs[source]; ok {
+					continue
+				}
+				if expr, err := parseWhereExpr(source); err == nil {
+					exprs[source] = expr
+				}
+			}
+		}
+	}
+}
+
+// _matchWhere evaluates the `#where` constraints of an object pattern
+// against the object `x`.
+func _matchWhere(st matchState, x interface{}, spec interface{}) (bool, error) {
+	var sources []string
+	switch v := spec.(type) {
+	case string:
+		sources = []string{v}
+	case []interface{}:
+		for _, item := range v {
+			source, ok := item.(string)
+			if !ok {
+				return false, fmt.Errorf("%s expressions must be strings, got %s", whereKey, formatValue(item))
+			}
+			sources = append(sources, source)
+		}
+	default:
+		return false, fmt.Errorf("%s must be an expression or an array of expressions, got %s",
+			whereKey, formatValue(spec))
+	}
+
+	matches := true
+	for _, source := range sources {
+		expr, ok := st.where[source]
+		if !ok {
+			var err error
+			expr, err = parseWhereExpr(source)
+			if err != nil {
+				return false, fmt.Errorf("invalid %s expression %q: %w", whereKey, source, err)
+			}
+		}
+		ev := whereEvaluator{root: st.root, current: x}
+		result, err := ev.eval(expr)
+		if err == nil {
+			b, ok := result.(bool)
+			if !ok {
+				err = fmt.Errorf("expected a boolean result, got %s", formatWhereValue(result))
+			} else if !b {
+				err = errors.New("is false" + ev.explain(expr))
+			}
+		}
+		if err != nil {
+			// reported at the object, since `#where` isn't a key of the document
+			st.mismatch("%s %q: %v", whereKey, source, err)
+			matches = false
+		}
+	}
+	return matches, nil
+}
+
+// whereNode is a node of the syntax tree of a `#where` expression.
+type whereNode interface{}
+
+type (
+	whereLiteral struct{ value interface{} }
+	wherePath    struct {
+		fromRoot bool
+		segments []wherePathSegment
+	}
+	whereUnary struct {
+		op      string
+		operand whereNode
+	}
+	whereBinary struct {
+		op          string
+		left, right whereNode
+	}
+	whereCall struct {
+		name string
+		args []whereNode
+	}
+)
+
+// wherePathSegment is an object key, an array index, or a wildcard
+// selecting all the items of an array (or values of an object).
+type wherePathSegment struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// whereFunctions holds the number of arguments of the functions available
+// in `#where` expressions.
+//
+//nolint:gochecknoglobals // an internal global here is more efficient than repeatedly creating the map in a hot path
+var whereFunctions = map[string]int{
+	"sum": 1,
+	"len": 1,
+	"min": 1,
+	"max": 1,
+	"abs": 1,
+}
+
+type whereTokenKind int
+
+const (
+	whereEOF whereTokenKind = iota
+	whereNumber
+	whereString
+	whereIdent
+	wherePointer
+	wherePunct
+)
+
+type whereToken struct {
+	kind   whereTokenKind
+	text   string
+	column int
+}
+
+// whereOperators lists the operators and punctuation, longest first.
+//
+//nolint:gochecknoglobals // an internal global here is more efficient than repeatedly creating the slice in a hot path
+var whereOperators = []string{
+	"==", "!=", "<=", ">=", "&&", "||",
+	"<", ">", "!", "+", "-", "*", "/", "(", ")", "[", "]", ".", ",", "$",
+}
+
+// tokenizeWhere splits a `#where` expression into tokens. A `/` where an
+// operand is expected starts a JSON Pointer, which extends up to the next
+// space, `)` or `,`.
+func tokenizeWhere(source string) ([]whereToken, error) {
+	var tokens []whereToken
+	expectOperand := true
+	for i := 0; i < len(source); {
+		r, size := utf8.DecodeRuneInString(source[i:])
+		column := utf8.RuneCountInString(source[:i]) + 1
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+			continue
+		case r == '/' && expectOperand:
+			end := strings.IndexAny(source[i:], " \t\n),")
+			if end < 0 {
+				end = len(source) - i
+			}
+			tokens = append(tokens, whereToken{kind: wherePointer, text: source[i : i+end], column: column})
+			i += end
+		case r >= '0' && r <= '9':
+			end := i
+			for end < len(source) && (source[end] >= '0' && source[end] <= '9' || source[end] == '.' ||
+				source[end] == 'e' || source[end] == 'E' ||
+				(source[end] == '-' || source[end] == '+') && (source[end-1] == 'e' || source[end-1] == 'E')) {
+				end++
+			}
+			tokens = append(tokens, whereToken{kind: whereNumber, text: source[i:end], column: column})
+			i = end
+		case r == '"' || r == '\'':
+			end := i + 1
+			for end < len(source) && source[end] != source[i] {
+				if source[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(source) {
+				return nil, fmt.Errorf("column %d: unterminated string", column)
+			}
+			text := source[i+1 : end]
+			if r == '"' {
+				var err error
+				text, err = strconv.Unquote(source[i : end+1])
+				if err != nil {
+					return nil, fmt.Errorf("column %d: invalid string: %w", column, err)
+				}
+			} else {
+				text = strings.ReplaceAll(strings.ReplaceAll(text, `\'`, `'`), `\\`, `\`)
+			}
+			tokens = append(tokens, whereToken{kind: whereString, text: text, column: column})
+			i = end + 1
+		case r == '_' || unicode.IsLetter(r):
+			end := i
+			for end < len(source) {
+				r, size := utf8.DecodeRuneInString(source[end:])
+				if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+					break
+				}
+				end += size
+			}
+			tokens = append(tokens, whereToken{kind: whereIdent, text: source[i:end], column: column})
+			i = end
+		default:
+			op := ""
+			for _, candidate := range whereOperators {
+				if strings.HasPrefix(source[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("column %d: unexpected character %q", column, r)
+			}
+			tokens = append(tokens, whereToken{kind: wherePunct, text: op, column: column})
+			i += len(op)
+		}
+		last := tokens[len(tokens)-1]
+		expectOperand = last.kind == wherePunct && last.text != ")" && last.text != "]"
+	}
+	return append(tokens, whereToken{kind: whereEOF, column: utf8.RuneCountInString(source) + 1}), nil
+}
+
+// whereParser is a recursive descent parser for `#where` expressions:
+//
+//	expr    = and { "||" and }
+//	and     = not { "&&" not }
+//	not     = "!" not | cmp
+//	cmp     = sum [ ( "==" | "!=" | "<" | "<=" | ">" | ">=" ) sum ]
+//	sum     = product { ( "+" | "-" ) product }
+//	product = unary { ( "*" | "/" ) unary }
+//	unary   = "-" unary | primary
+//	primary = NUMBER | STRING | "true" | "false" | "null" | POINTER | "(" expr ")"
+//	        | FUNCTION "(" expr ")" | path
+//	path    = ( "$" | IDENT ) { "." IDENT | "[" ( INTEGER | STRING | "*" ) "]" }
+type whereParser struct {
+	tokens []whereToken
+	pos    int
+}
+
+func parseWhereExpr(source string) (whereNode, error) {
+	tokens, err := tokenizeWhere(source)
+	if err != nil {
+		return nil, err
+	}
+	p := whereParser{tokens: tokens}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != whereEOF {
+		return nil, p.unexpected(tok)
+	}
+	return node, nil
+}
+
+func (p *whereParser) peek() whereToken {
+	return p.tokens[p.pos]
+}
+
+func (p *whereParser) next() whereToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != whereEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *whereParser) accept(ops ...string) (string, bool) {
+	tok := p.peek()
+	if tok.kind != wherePunct {
+		return "", false
+	}
+	for _, op := range ops {
+		if tok.text == op {
+			p.pos++
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *whereParser) expect(op string) error {
+	if _, ok := p.accept(op); !ok {
+		return fmt.Errorf("column %d: expected %q, got %s", p.peek().column, op, describeWhereToken(p.peek()))
+	}
+	return nil
+}
+
+func (p *whereParser) unexpected(tok whereToken) error {
+	return fmt.Errorf("column %d: unexpected %s", tok.column, describeWhereToken(tok))
+}
+
+func describeWhereToken(tok whereToken) string {
+	if tok.kind == whereEOF {
+		return "end of expression"
+	}
+	return strconv.Quote(tok.text)
+}
+
+func (p *whereParser) parseBinary(parseOperand func() (whereNode, error), ops ...string) (whereNode, error) {
+	left, err := parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept(ops...)
+		if !ok {
+			return left, nil
+		}
+		right, err := parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		left = whereBinary{op: op, left: left, right: right}
+	}
+}
+
+func (p *whereParser) parseOr() (whereNode, error) {
+	return p.parseBinary(p.parseAnd, "||")
+}
+
+func (p *whereParser) parseAnd() (whereNode, error) {
+	return p.parseBinary(p.parseNot, "&&")
+}
+
+func (p *whereParser) parseNot() (whereNode, error) {
+	if _, ok := p.accept("!"); ok {
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return whereUnary{op: "!", operand: operand}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *whereParser) parseComparison() (whereNode, error) {
+	left, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	op, ok := p.accept("==", "!=", "<=", ">=", "<", ">")
+	if !ok {
+		return left, nil
+	}
+	right, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	return whereBinary{op: op, left: left, right: right}, nil
+}
+
+func (p *whereParser) parseSum() (whereNode, error) {
+	return p.parseBinary(p.parseProduct, "+", "-")
+}
+
+func (p *whereParser) parseProduct() (whereNode, error) {
+	return p.parseBinary(p.parseUnary, "*", "/")
+}
+
+func (p *whereParser) parseUnary() (whereNode, error) {
+	if _, ok := p.accept("-"); ok {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return whereUnary{op: "-", operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+//nolint:cyclop // a flat switch on the token is the most legible form here
+func (p *whereParser) parsePrimary() (whereNode, error) {
+	tok := p.next()
+	switch tok.kind {
+	case whereNumber:
+		r, ok := new(big.Rat).SetString(tok.text)
+		if !ok {
+			return nil, fmt.Errorf("column %d: invalid number %q", tok.column, tok.text)
+		}
+		return whereLiteral{value: r}, nil
+	case whereString:
+		return whereLiteral{value: tok.text}, nil
+	case wherePointer:
+		return parseWherePointer(tok)
+	case whereIdent:
+		switch tok.text {
+		case "true", "false":
+			return whereLiteral{value: tok.text == "true"}, nil
+		case "null":
+			return whereLiteral{value: nil}, nil
+		}
+		if _, ok := p.accept("("); ok {
+			return p.parseCall(tok)
+		}
+		return p.parsePath(wherePath{segments: []wherePathSegment{{key: tok.text}}})
+	case wherePunct:
+		switch tok.text {
+		case "$":
+			return p.parsePath(wherePath{fromRoot: true})
+		case "(":
+			node, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			return node, p.expect(")")
+		}
+	case whereEOF:
+	}
+	return nil, p.unexpected(tok)
+}
+
+func (p *whereParser) parseCall(name whereToken) (whereNode, error) {
+	arity, ok := whereFunctions[name.text]
+	if !ok {
+		return nil, fmt.Errorf("column %d: unknown function %q", name.column, name.text)
+	}
+	call := whereCall{name: name.text}
+	for {
+		if _, ok := p.accept(")"); ok && len(call.args) == 0 {
+			break
+		}
+		arg, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		call.args = append(call.args, arg)
+		if _, ok := p.accept(","); !ok {
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+	if len(call.args) != arity {
+		return nil, fmt.Errorf("column %d: %s() expects %d argument(s), got %d", name.column, name.text, arity,
+			len(call.args))
+	}
+	return call, nil
+}
+
+func (p *whereParser) parsePath(path wherePath) (whereNode, error) {
+	for {
+		if _, ok := p.accept("."); ok {
+			tok := p.next()
+			if tok.kind != whereIdent {
+				return nil, p.unexpected(tok)
+			}
+			path.segments = append(path.segments, wherePathSegment{key: tok.text})
+			continue
+		}
+		if _, ok := p.accept("["); ok {
+			tok := p.next()
+			switch {
+			case tok.kind == wherePunct && tok.text == "*":
+				path.segments = append(path.segments, wherePathSegment{wildcard: true})
+			case tok.kind == whereString:
+				path.segments = append(path.segments, wherePathSegment{key: tok.text})
+			case tok.kind == whereNumber:
+				index, err := strconv.Atoi(tok.text)
+				if err != nil || index < 0 {
+					return nil, fmt.Errorf("column %d: invalid array index %q", tok.column, tok.text)
+				}
+				path.segments = append(path.segments, wherePathSegment{index: index, isIndex: true})
+			default:
+				return nil, p.unexpected(tok)
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		return path, nil
+	}
+}
+
+// parseWherePointer converts a JSON Pointer (resolved from the document
+// root) to a path.
+func parseWherePointer(tok whereToken) (whereNode, error) {
+	path := wherePath{fromRoot: true}
+	for _, token := range strings.Split(tok.text, "/")[1:] {
+		if strings.Contains(strings.ReplaceAll(strings.ReplaceAll(token, "~0", ""), "~1", ""), "~") {
+			return nil, fmt.Errorf("column %d: invalid JSON Pointer %q", tok.column, tok.text)
+		}
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		segment := wherePathSegment{key: token}
+		if index, err := strconv.Atoi(token); err == nil && index >= 0 {
+			segment.index, segment.isIndex = index, true
+		}
+		path.segments = append(path.segments, segment)
+	}
+	return path, nil
+}
+
+// whereEvaluator evaluates `#where` expressions. Paths are resolved from the
+// document root or from the current object; numbers are represented as
+// *big.Rat (so that decimal sums are exact), path wildcards produce
+// []interface{}.
+type whereEvaluator struct {
+	root    interface{}
+	current interface{}
+}
+
+func (ev whereEvaluator) eval(node whereNode) (interface{}, error) {
+	switch n := node.(type) {
+	case whereLiteral:
+		return n.value, nil
+	case wherePath:
+		return ev.evalPath(n)
+	case whereUnary:
+		return ev.evalUnary(n)
+	case whereBinary:
+		return ev.evalBinary(n)
+	case whereCall:
+		return ev.evalCall(n)
+	}
+	return nil, fmt.Errorf("unsupported expression node %T", node)
+}
+
+// explain describes the operands of a comparison, to clarify why it is
+// false.
+func (ev whereEvaluator) explain(node whereNode) string {
+	n, ok := node.(whereBinary)
+	if !ok || n.op == "&&" || n.op == "||" {
+		return ""
+	}
+	left, err := ev.eval(n.left)
+	if err != nil {
+		return ""
+	}
+	right, err := ev.eval(n.right)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf(" (%s %s %s)", formatWhereValue(left), n.op, formatWhereValue(right))
+}
+
+func (ev whereEvaluator) evalPath(path wherePath) (interface{}, error) {
+	values := []interface{}{ev.current}
+	if path.fromRoot {
+		values = []interface{}{ev.root}
+	}
+	multi := false
+	for _, segment := range path.segments {
+		var next []interface{}
+		for _, v := range values {
+			selected, err := selectWhereSegment(v, segment)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", formatWherePath(path), err)
+			}
+			next = append(next, selected...)
+		}
+		values = next
+		multi = multi || segment.wildcard
+	}
+	if multi {
+		if values == nil {
+			values = []interface{}{}
+		}
+		return values, nil
+	}
+	return values[0], nil
+}
+
+func selectWhereSegment(v interface{}, segment wherePathSegment) ([]interface{}, error) {
+	switch container := v.(type) {
+	case map[string]interface{}:
+		if segment.wildcard {
+			values := make([]interface{}, 0, len(container))
+			for _, key := range sortedMapKeys(container) {
+				values = append(values, container[key])
+			}
+			return values, nil
+		}
+		item, ok := container[segment.key]
+		if !ok {
+			return nil, fmt.Errorf("missing key %q", segment.key)
+		}
+		return []interface{}{item}, nil
+	case []interface{}:
+		if segment.wildcard {
+			return container, nil
+		}
+		if !segment.isIndex {
+			return nil, fmt.Errorf("can't select key %q of an array", segment.key)
+		}
+		if segment.index >= len(container) {
+			return nil, fmt.Errorf("index %d out of range", segment.index)
+		}
+		return []interface{}{container[segment.index]}, nil
+	}
+	return nil, fmt.Errorf("can't select items of %s", formatValue(v))
+}
+
+func formatWherePath(path wherePath) string {
+	var sb strings.Builder
+	if path.fromRoot {
+		sb.WriteString("$")
+	}
+	for i, segment := range path.segments {
+		switch {
+		case segment.wildcard:
+			sb.WriteString("[*]")
+		case segment.isIndex:
+			sb.WriteString("[" + strconv.Itoa(segment.index) + "]")
+		default:
+			if i > 0 || path.fromRoot {
+				sb.WriteString(".")
+			}
+			sb.WriteString(segment.key)
+		}
+	}
+	return sb.String()
+}
+
+func (ev whereEvaluator) evalUnary(n whereUnary) (interface{}, error) {
+	v, err := ev.eval(n.operand)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "!" {
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("can't negate %s", formatWhereValue(v))
+		}
+		return !b, nil
+	}
+	r, ok := whereNumberValue(v)
+	if !ok {
+		return nil, fmt.Errorf("can't negate %s", formatWhereValue(v))
+	}
+	return new(big.Rat).Neg(r), nil
+}
+
+//nolint:cyclop // a flat switch on the operator is the most legible form here
+func (ev whereEvaluator) evalBinary(n whereBinary) (interface{}, error) {
+	left, err := ev.eval(n.left)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "&&" || n.op == "||" {
+		l, ok := left.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%s: expected a boolean, got %s", n.op, formatWhereValue(left))
+		}
+		if l == (n.op == "||") {
+			// short circuit
+			return l, nil
+		}
+		right, err := ev.eval(n.right)
+		if err != nil {
+			return nil, err
+		}
+		r, ok := right.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%s: expected a boolean, got %s", n.op, formatWhereValue(right))
+		}
+		return r, nil
+	}
+	right, err := ev.eval(n.right)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "+", "-", "*", "/":
+		l, lOk := whereNumberValue(left)
+		r, rOk := whereNumberValue(right)
+		if !lOk || !rOk {
+			return nil, fmt.Errorf("can't compute %s %s %s", formatWhereValue(left), n.op, formatWhereValue(right))
+		}
+		switch n.op {
+		case "+":
+			return new(big.Rat).Add(l, r), nil
+		case "-":
+			return new(big.Rat).Sub(l, r), nil
+		case "*":
+			return new(big.Rat).Mul(l, r), nil
+		}
+		if r.Sign() == 0 {
+			return nil, errors.New("division by zero")
+		}
+		return new(big.Rat).Quo(l, r), nil
+	}
+
+	cmp, err := compareWhereValues(left, right, n.op == "==" || n.op == "!=")
+	if err != nil {
+		return nil, fmt.Errorf("can't compare %s %s %s: %w", formatWhereValue(left), n.op,
+			formatWhereValue(right), err)
+	}
+	var result bool
+	switch n.op {
+	case "==":
+		result = cmp == 0
+	case "!=":
+		result = cmp != 0
+	case "<":
+		result = cmp < 0
+	case "<=":
+		result = cmp <= 0
+	case ">":
+		result = cmp > 0
+	case ">=":
+		result = cmp >= 0
+	}
+	return result, nil
+}
+
+// compareWhereValues compares numbers, datetimes (RFC3339 strings) and
+// strings, returning -1, 0 or +1. Other values can only be compared for
+// equality (`equality` true), in which case 1 means different.
+func compareWhereValues(left interface{}, right interface{}, equality bool) (int, error) {
+	if l, ok := whereNumberValue(left); ok {
+		if r, ok := whereNumberValue(right); ok {
+			return l.Cmp(r), nil
+		}
+	}
+	if l, ok := parseDefaultDateTime(left); ok {
+		if r, ok := parseDefaultDateTime(right); ok {
+			return compareTimes(l, r), nil
+		}
+	}
+	if l, ok := left.(string); ok {
+		if r, ok := right.(string); ok {
+			return strings.Compare(l, r), nil
+		}
+	}
+	if !equality {
+		return 0, errors.New("values aren't both numbers, datetimes or strings")
+	}
+	if reflect.DeepEqual(left, right) {
+		return 0, nil
+	}
+	return 1, nil
+}
+
+func compareTimes(l time.Time, r time.Time) int {
+	switch {
+	case l.Before(r):
+		return -1
+	case l.After(r):
+		return 1
+	}
+	return 0
+}
+
+func (ev whereEvaluator) evalCall(n whereCall) (interface{}, error) {
+	arg, err := ev.eval(n.args[0])
+	if err != nil {
+		return nil, err
+	}
+	if n.name == "abs" {
+		r, ok := whereNumberValue(arg)
+		if !ok {
+			return nil, fmt.Errorf("abs(): expected a number, got %s", formatWhereValue(arg))
+		}
+		return new(big.Rat).Abs(r), nil
+	}
+	if n.name == "len" {
+		switch v := arg.(type) {
+		case string:
+			return new(big.Rat).SetInt64(int64(utf8.RuneCountInString(v))), nil
+		case []interface{}:
+			return new(big.Rat).SetInt64(int64(len(v))), nil
+		case map[string]interface{}:
+			return new(big.Rat).SetInt64(int64(len(v))), nil
+		}
+		return nil, fmt.Errorf("len(): expected a string, array or object, got %s", formatWhereValue(arg))
+	}
+
+	items, ok := arg.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s(): expected an array, got %s", n.name, formatWhereValue(arg))
+	}
+	var result *big.Rat
+	for _, item := range items {
+		r, ok := whereNumberValue(item)
+		if !ok {
+			return nil, fmt.Errorf("%s(): expected numbers, got %s", n.name, formatWhereValue(item))
+		}
+		switch {
+		case result == nil:
+			result = new(big.Rat).Set(r)
+		case n.name == "sum":
+			result.Add(result, r)
+		case n.name == "min" && r.Cmp(result) < 0, n.name == "max" && r.Cmp(result) > 0:
+			result.Set(r)
+		}
+	}
+	if result == nil {
+		if n.name != "sum" {
+			return nil, fmt.Errorf("%s(): empty array", n.name)
+		}
+		result = new(big.Rat)
+	}
+	return result, nil
+}
+
+// whereNumberValue returns the value of a number as a *big.Rat. Floating
+// point numbers are converted from their shortest decimal representation,
+// so that e.g. 0.1 + 0.2 == 0.3.
+func whereNumberValue(v interface{}) (*big.Rat, bool) {
+	if r, ok := v.(*big.Rat); ok {
+		return r, true
+	}
+	xV := reflect.ValueOf(v)
+	if !xV.IsValid() || !isNumberKind(xV.Kind()) {
+		return nil, false
+	}
+	var text string
+	switch {
+	case xV.CanInt():
+		text = strconv.FormatInt(xV.Int(), 10)
+	case xV.CanUint():
+		text = strconv.FormatUint(xV.Uint(), 10)
+	default:
+		text = strconv.FormatFloat(xV.Float(), 'g', -1, xV.Type().Bits())
+	}
+	return new(big.Rat).SetString(text)
+}
+
+func formatWhereValue(v interface{}) string {
+	if r, ok := v.(*big.Rat); ok {
+		if r.IsInt() {
+			return r.Num().String()
+		}
+		f, _ := r.Float64()
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+	return formatValue(v)
+}
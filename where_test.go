@@ -0,0 +1,99 @@
+package matcher_test
+
+import (
+	"strings"
+	"testing"
+
+	matcher "github.com/panta/go-json-matcher"
+)
+
+func TestWhere(t *testing.T) {
+	const order = `{
+  "created": "2024-05-01T10:00:00Z",
+  "updated": "2024-05-01T12:00:00+01:00",
+  "start_date": "2024-05-01",
+  "end_date": "2024-05-03",
+  "total": 0.6,
+  "items": [ { "price": 0.1 }, { "price": 0.2 }, { "price": 0.3 } ],
+  "page": 2,
+  "total_pages": 5,
+  "meta": { "page_size": 3, "max_items": 10 },
+  "a/b": 1
+}`
+	tests := []struct {
+		name       string
+		jSpec      string
+		want       bool
+		wantReason string
+		wantPath   string
+		wantErr    string
+	}{
+		{name: "datetimes", jSpec: `{ "#where": "updated >= created" }`, want: true},
+		{name: "datetimes-fail", jSpec: `{ "#where": "updated < created" }`,
+			wantReason: `#where "updated < created": is false ` +
+				`("2024-05-01T12:00:00+01:00" < "2024-05-01T10:00:00Z")`},
+		{name: "strings", jSpec: `{ "#where": "end_date > start_date" }`, want: true},
+		{name: "sum", jSpec: `{ "#where": "total == sum(items[*].price)" }`, want: true},
+		{name: "sum-fail", jSpec: `{ "#where": "total == sum(items[*].price) + 1" }`,
+			wantReason: `#where "total == sum(items[*].price) + 1": is false (0.6 == 1.6)`},
+		{name: "numbers", jSpec: `{ "#where": "page <= total_pages && page > 0" }`, want: true},
+		{name: "list", jSpec: `{ "#where": [ "page <= total_pages", "len(items) <= meta.max_items" ] }`,
+			want: true},
+		{name: "arithmetic", jSpec: `{ "#where": "-(len(items) - 3 * meta.page_size / 3) == 0" }`, want: true},
+		{name: "functions", jSpec: `{ "#where": "min(items[*].price) < max(items[*].price) && abs(-1) == 1" }`,
+			want: true},
+		{name: "literals", jSpec: `{ "#where": "!(page == null) && 'x' != \"y\" && true || false" }`,
+			want: true},
+		{name: "index", jSpec: `{ "#where": "items[2].price == 0.3 && items[0][\"price\"] < 1" }`, want: true},
+		{name: "root", jSpec: `{ "meta": { "#where": "page_size < $.total_pages" } }`, want: true},
+		{name: "pointer", jSpec: `{ "meta": { "#where": "page_size == /meta/page_size && /a~1b == 1" } }`,
+			want: true},
+		{name: "pointer-index", jSpec: `{ "#where": "/items/1/price == 0.2" }`, want: true},
+		{name: "nested-fail", jSpec: `{ "meta": { "#where": "page_size > max_items" } }`,
+			wantReason: `#where "page_size > max_items": is false (3 > 10)`, wantPath: "/meta"},
+		{name: "missing-field", jSpec: `{ "#where": "deleted > created" }`,
+			wantReason: `#where "deleted > created": deleted: missing key "deleted"`},
+		{name: "type-mismatch", jSpec: `{ "#where": "page < created" }`,
+			wantReason: `#where "page < created": can't compare 2 < "2024-05-01T10:00:00Z": ` +
+				`values aren't both numbers, datetimes or strings`},
+		{name: "non-boolean", jSpec: `{ "#where": "page + 1" }`,
+			wantReason: `#where "page + 1": expected a boolean result, got 3`},
+		{name: "division-by-zero", jSpec: `{ "#where": "page / 0 == 1" }`,
+			wantReason: `#where "page / 0 == 1": division by zero`},
+		{name: "syntax-error", jSpec: `{ "#where": "page <= " }`,
+			wantErr: `invalid #where expression "page <= ": column 9: unexpected end of expression`},
+		{name: "unknown-function", jSpec: `{ "#where": "avg(items[*].price) > 0" }`,
+			wantErr: `column 1: unknown function "avg"`},
+		{name: "unexpected-character", jSpec: `{ "#where": "page = 2" }`,
+			wantErr: `column 6: unexpected character '='`},
+		{name: "not-an-expression", jSpec: `{ "#where": 42 }`,
+			wantErr: `#where must be an expression or an array of expressions, got 42`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := matcher.Compile([]byte(tt.jSpec))
+			var report *matcher.Report
+			if err == nil {
+				report, err = p.Match([]byte(order))
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Match() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Match() error = %v", err)
+			}
+			if report.Matches() != tt.want {
+				t.Errorf("Match() matches = %v, want %v (%s)", report.Matches(), tt.want, report)
+			}
+			if tt.wantReason != "" && (len(report.Mismatches) != 1 || report.Mismatches[0].Reason != tt.wantReason) {
+				t.Errorf("Match() got = %v, want reason %q", report.Mismatches, tt.wantReason)
+			}
+			if tt.wantPath != "" && (len(report.Mismatches) != 1 || report.Mismatches[0].Path != tt.wantPath) {
+				t.Errorf("Match() got = %v, want path %q", report.Mismatches, tt.wantPath)
+			}
+		})
+	}
+}
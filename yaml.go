@@ -0,0 +1,328 @@
+package matcher
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	yamlNullTag      = "!!null"
+	yamlBoolTag      = "!!bool"
+	yamlIntTag       = "!!int"
+	yamlFloatTag     = "!!float"
+	yamlStrTag       = "!!str"
+	yamlTimestampTag = "!!timestamp"
+	yamlMergeTag     = "!!merge"
+)
+
+// sourcePosition is the position of a value in the source of a YAML or
+// (relaxed) JSON document.
+type sourcePosition struct {
+	line   int
+	column int
+}
+
+// YAMLMatches checks if the YAML document in `doc` satisfies the pattern in
+// `pattern`, which can be written in YAML as well.
+// Since JSON is a subset of YAML, either argument can also be JSON.
+// YAML values are normalised to the representation used for JSON: all
+// numbers become float64, mapping keys are converted to strings and
+// timestamps are compared as strings (RFC3339 when they carry a time).
+// Note that in YAML an unquoted `#` preceded by a space starts a comment,
+// so markers must be quoted (e.g. `id: "#uuid"`).
+func YAMLMatches(doc []byte, pattern []byte) (bool, error) {
+	p, err := CompileYAML(pattern)
+	if err != nil {
+		return false, err
+	}
+	report, err := p.MatchYAML(doc)
+	if err != nil {
+		return false, err
+	}
+	return report.Matches(), nil
+}
+
+// CompileYAML parses the YAML (or JSON) pattern specifier in `pattern`,
+// accepting the same options as Compile.
+func CompileYAML(pattern []byte, opts ...Option) (*Pattern, error) {
+	spec, positions, err := unmarshalYAML(pattern)
+	if err != nil {
+		return nil, withSentinel(ErrInvalidPattern, fmt.Errorf("can't unmarshal pattern argument: %w", err))
+	}
+	p, err := newPattern(spec, opts)
+	if err != nil {
+		return nil, locatePatternError(err, func() map[string]sourcePosition { return positions })
+	}
+	return p, nil
+}
+
+// MatchYAML checks the YAML document in `doc` against the pattern and
+// returns a report listing every mismatch found. The mismatches carry the
+// line and column of the offending values in `doc`.
+func (p *Pattern) MatchYAML(doc []byte) (*Report, error) {
+	x, positions, err := unmarshalYAML(doc)
+	if err != nil {
+		return nil, withSentinel(ErrInvalidDocument, fmt.Errorf("can't unmarshal left argument: %w", err))
+	}
+	report, err := p.matchValue(x)
+	if err != nil {
+		return nil, err
+	}
+	for i := range report.Mismatches {
+		m := &report.Mismatches[i]
+		if pos, ok := lookupPosition(positions, m.Path); ok {
+			m.Line, m.Column = pos.line, pos.column
+		}
+	}
+	return report, nil
+}
+
+// lookupPosition returns the position of the value at `path`, or of its
+// closest ancestor when the value doesn't exist in the document.
+func lookupPosition(positions map[string]sourcePosition, path string) (sourcePosition, bool) {
+	for {
+		if pos, ok := positions[path]; ok {
+			return pos, true
+		}
+		i := strings.LastIndex(path, "/")
+		if i < 0 {
+			return sourcePosition{}, false
+		}
+		path = path[:i]
+	}
+}
+
+// unmarshalYAML decodes the first YAML document in `data` into the value
+// model used for JSON, returning also the position of every value indexed
+// by its JSON Pointer. Duplicate mapping keys are rejected, as yaml.v3 does
+// when decoding into maps.
+func unmarshalYAML(data []byte) (interface{}, map[string]sourcePosition, error) {
+	return decodeYAML(data, false)
+}
+
+// decodeYAML is like unmarshalYAML, optionally accepting duplicate mapping
+// keys (the last value wins), e.g. to report them as lint diagnostics.
+func decodeYAML(data []byte, allowDuplicateKeys bool) (interface{}, map[string]sourcePosition, error) {
+	var root yaml.Node
+	err := yaml.NewDecoder(bytes.NewReader(data)).Decode(&root)
+	if errors.Is(err, io.EOF) {
+		// an empty document is a null value, as in YAML
+		return nil, map[string]sourcePosition{}, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	c := &yamlConverter{positions: map[string]sourcePosition{}, allowDuplicateKeys: allowDuplicateKeys}
+	x, err := c.convertNode(&root, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	return x, c.positions, nil
+}
+
+// maxYAMLAliasNodes is the maximum number of nodes that can be produced by
+// expanding aliases in a YAML document, so that documents like the
+// "billion laughs" one can't exhaust the memory.
+const maxYAMLAliasNodes = 100000
+
+// yamlConverter converts YAML nodes to the value model used for JSON.
+type yamlConverter struct {
+	// positions are the positions of the converted values, indexed by their
+	// JSON Pointer
+	positions map[string]sourcePosition
+	// aliasDepth is the number of aliases being expanded
+	aliasDepth int
+	// aliasNodes is the number of nodes produced expanding aliases
+	aliasNodes int
+	// allowDuplicateKeys accepts duplicate mapping keys, keeping the last
+	// value
+	allowDuplicateKeys bool
+}
+
+// expandAlias converts the node referenced by the alias `node`, counting
+// the nodes produced against maxYAMLAliasNodes.
+func (c *yamlConverter) expandAlias(node *yaml.Node, convert func(node *yaml.Node) error) error {
+	c.aliasDepth++
+	defer func() { c.aliasDepth-- }()
+	return convert(node.Alias)
+}
+
+func (c *yamlConverter) convertNode(node *yaml.Node, path string) (interface{}, error) {
+	if c.aliasDepth > 0 {
+		c.aliasNodes++
+		if c.aliasNodes > maxYAMLAliasNodes {
+			return nil, fmt.Errorf("line %d, column %d: aliases expand to more than %d nodes",
+				node.Line, node.Column, maxYAMLAliasNodes)
+		}
+	}
+	if _, ok := c.positions[path]; !ok {
+		c.positions[path] = sourcePosition{line: node.Line, column: node.Column}
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+		return c.convertNode(node.Content[0], path)
+	case yaml.AliasNode:
+		var x interface{}
+		err := c.expandAlias(node, func(alias *yaml.Node) error {
+			var err error
+			x, err = c.convertNode(alias, path)
+			return err
+		})
+		return x, err
+	case yaml.SequenceNode:
+		items := make([]interface{}, 0, len(node.Content))
+		for i, child := range node.Content {
+			item, err := c.convertNode(child, path+"/"+strconv.Itoa(i))
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	case yaml.MappingNode:
+		m := map[string]interface{}{}
+		err := c.convertMapping(node, path, m)
+		if err != nil {
+			return nil, err
+		}
+		return m, nil
+	case yaml.ScalarNode:
+		return convertYAMLScalar(node)
+	}
+	return nil, fmt.Errorf("line %d, column %d: unsupported YAML node kind %v", node.Line, node.Column, node.Kind)
+}
+
+// convertMapping adds the key/value pairs of the mapping `node` to `m`,
+// honouring merge keys (`<<`).
+func (c *yamlConverter) convertMapping(node *yaml.Node, path string, m map[string]interface{}) error {
+	seen := map[string]*yaml.Node{}
+	//nolint:gomnd // the "magic" literal constant 2 here is clearer than a synthetic constant symbol
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valueNode := node.Content[i], node.Content[i+1]
+		if keyNode.ShortTag() == yamlMergeTag {
+			err := c.mergeMapping(valueNode, path, m)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		key, err := c.keyString(keyNode)
+		if err != nil {
+			return err
+		}
+		if previous, ok := seen[key]; ok && !c.allowDuplicateKeys {
+			return fmt.Errorf("line %d, column %d: mapping key %q already defined at line %d, column %d",
+				keyNode.Line, keyNode.Column, key, previous.Line, previous.Column)
+		}
+		seen[key] = keyNode
+		value, err := c.convertNode(valueNode, path+"/"+escapePointerToken(key))
+		if err != nil {
+			return err
+		}
+		m[key] = value
+	}
+	return nil
+}
+
+func (c *yamlConverter) mergeMapping(node *yaml.Node, path string, m map[string]interface{}) error {
+	//nolint:exhaustive // other node kinds can't be merged
+	switch node.Kind {
+	case yaml.AliasNode:
+		return c.expandAlias(node, func(alias *yaml.Node) error {
+			return c.mergeMapping(alias, path, m)
+		})
+	case yaml.MappingNode:
+		return c.convertMapping(node, path, m)
+	case yaml.SequenceNode:
+		for _, child := range node.Content {
+			err := c.mergeMapping(child, path, m)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("line %d, column %d: merge value must be a mapping", node.Line, node.Column)
+}
+
+// yamlKeyString converts a mapping key to the string used for the key in
+// the JSON value model: scalars are used verbatim, other nodes are
+// converted to their compact JSON representation.
+func yamlKeyString(node *yaml.Node) (string, error) {
+	c := &yamlConverter{positions: map[string]sourcePosition{}}
+	return c.keyString(node)
+}
+
+// keyString is like yamlKeyString, counting the nodes produced expanding
+// aliases against the budget of the converter.
+func (c *yamlConverter) keyString(node *yaml.Node) (string, error) {
+	if node.Kind == yaml.AliasNode {
+		var key string
+		err := c.expandAlias(node, func(alias *yaml.Node) error {
+			var err error
+			key, err = c.keyString(alias)
+			return err
+		})
+		return key, err
+	}
+	if node.Kind == yaml.ScalarNode {
+		if node.ShortTag() == yamlNullTag {
+			return "null", nil
+		}
+		return node.Value, nil
+	}
+	// the positions of the values in the key are of no use
+	positions := c.positions
+	c.positions = map[string]sourcePosition{}
+	defer func() { c.positions = positions }()
+	key, err := c.convertNode(node, "")
+	if err != nil {
+		return "", err
+	}
+	return formatValue(key), nil
+}
+
+func convertYAMLScalar(node *yaml.Node) (interface{}, error) {
+	switch node.ShortTag() {
+	case yamlNullTag:
+		return nil, nil
+	case yamlBoolTag:
+		var b bool
+		err := node.Decode(&b)
+		return b, err
+	case yamlIntTag, yamlFloatTag:
+		var f float64
+		err := node.Decode(&f)
+		if err == nil && (math.IsInf(f, 0) || math.IsNaN(f)) {
+			err = fmt.Errorf("line %d, column %d: %q can't be represented as a JSON number",
+				node.Line, node.Column, node.Value)
+		}
+		return f, err
+	case yamlTimestampTag:
+		if _, err := time.Parse("2006-01-02", node.Value); err == nil {
+			return node.Value, nil
+		}
+		var t time.Time
+		err := node.Decode(&t)
+		if err != nil {
+			return nil, err
+		}
+		return t.Format(time.RFC3339Nano), nil
+	case yamlStrTag:
+		return node.Value, nil
+	}
+	// custom tags: keep the textual value
+	return node.Value, nil
+}
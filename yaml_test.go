@@ -0,0 +1,212 @@
+package matcher_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	matcher "github.com/panta/go-json-matcher"
+)
+
+func TestYAMLMatches(t *testing.T) {
+	type args struct {
+		doc     string
+		pattern string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    bool
+		wantErr bool
+	}{
+		{name: "scalar", args: args{
+			doc:     `42`,
+			pattern: `"#number"`,
+		}, want: true},
+		{name: "mapping", args: args{
+			doc: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  labels:
+    app: web
+spec:
+  replicas: 3
+  paused: false
+`,
+			pattern: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: "#string"
+  namespace: "#notpresent"
+spec:
+  replicas: 3
+  paused: "#boolean"
+`,
+		}, want: true},
+		{name: "json-pattern", args: args{
+			doc: `
+id: adb43c69-f8d9-4108-a2da-d740a2a800ec
+tags: [ society, essays ]
+`,
+			pattern: `{ "id": "#uuid-v4", "tags": [ "#array-of", "#string" ] }`,
+		}, want: true},
+		{name: "json-document", args: args{
+			doc:     `{ "replicas": 3 }`,
+			pattern: `replicas: 3`,
+		}, want: true},
+		{name: "integers", args: args{
+			doc:     `{ dec: 31, hex: 0x1F, oct: 0o37 }`,
+			pattern: `{ dec: 31.0, hex: 31, oct: 31 }`,
+		}, want: true},
+		{name: "non-string-keys", args: args{
+			doc: `
+200: ok
+404: not found
+true: yes
+`,
+			pattern: `{ "200": "ok", "404": "#string", "true": "yes" }`,
+		}, want: true},
+		{name: "timestamps", args: args{
+			doc: `
+date: 2022-07-20
+datetime: 2022-07-20T09:56:29Z
+spaced: 2001-12-14 21:59:43.10
+`,
+			pattern: `{ date: "#date", datetime: "#datetime", spaced: "2001-12-14T21:59:43.1Z" }`,
+		}, want: true},
+		{name: "anchors-and-merge", args: args{
+			doc: `
+base: &base
+  image: nginx
+  port: 80
+web:
+  <<: *base
+  port: 8080
+`,
+			pattern: `{ web: { image: nginx, port: 8080 } }`,
+		}, want: true},
+		{name: "null-and-empty", args: args{
+			doc:     `{ a: ~, b: null, c: }`,
+			pattern: `{ a: "#null", b: null, c: "#null" }`,
+		}, want: true},
+		{name: "mismatch", args: args{
+			doc:     `{ replicas: "3" }`,
+			pattern: `{ replicas: "#number" }`,
+		}, want: false},
+		{name: "empty-document", args: args{
+			doc:     ``,
+			pattern: `"#null"`,
+		}, want: true},
+		{name: "bad-document", args: args{
+			doc:     "a: [1, 2",
+			pattern: `"#ignore"`,
+		}, wantErr: true},
+		{name: "bad-pattern", args: args{
+			doc:     `a: 1`,
+			pattern: "a: [1, 2",
+		}, wantErr: true},
+		{name: "duplicate-key", args: args{
+			doc:     "a: 1\nb: 2\na: 3\n",
+			pattern: `a: "#number"`,
+		}, wantErr: true},
+		{name: "duplicate-key-pattern", args: args{
+			doc:     `a: 1`,
+			pattern: "a: \"#number\"\na: \"#string\"\n",
+		}, wantErr: true},
+		{name: "infinite-number", args: args{
+			doc:     `a: .inf`,
+			pattern: `a: "#number"`,
+		}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := matcher.YAMLMatches([]byte(tt.args.doc), []byte(tt.args.pattern))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("YAMLMatches() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("YAMLMatches() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchYAMLPositions(t *testing.T) {
+	doc := `
+metadata:
+  name: web
+spec:
+  replicas: "3"
+  containers:
+    - image: nginx
+      ports: []
+`
+	p, err := matcher.CompileYAML([]byte(`
+metadata:
+  name: web
+spec:
+  replicas: "#number"
+  containers:
+    - image: nginx
+      ports: "#array"
+      command: "#present"
+`))
+	if err != nil {
+		t.Fatalf("CompileYAML() error = %v", err)
+	}
+	report, err := p.MatchYAML([]byte(doc))
+	if err != nil {
+		t.Fatalf("MatchYAML() error = %v", err)
+	}
+	want := []matcher.Mismatch{
+		{Path: "/spec/containers/0/command", Reason: "missing key", Line: 7, Column: 7},
+		{Path: "/spec/replicas", Reason: `"3" doesn't match "#number"`, Line: 5, Column: 13},
+	}
+	if len(report.Mismatches) != len(want) {
+		t.Fatalf("MatchYAML() mismatches = %v, want %v", report.Mismatches, want)
+	}
+	for i := range want {
+		if report.Mismatches[i] != want[i] {
+			t.Errorf("MatchYAML() mismatch[%d] = %+v, want %+v", i, report.Mismatches[i], want[i])
+		}
+	}
+	wantString := `/spec/replicas (line 5, column 13): "3" doesn't match "#number"`
+	if got := report.Mismatches[1].String(); got != wantString {
+		t.Errorf("Mismatch.String() = %q, want %q", got, wantString)
+	}
+}
+
+func TestMatchYAMLDuplicateKey(t *testing.T) {
+	_, err := matcher.MustCompile(`"#ignore"`).MatchYAML([]byte("a: 1\nnested:\n  b: 2\n  b: 3\n"))
+	want := `line 4, column 3: mapping key "b" already defined at line 3, column 3`
+	if !errors.Is(err, matcher.ErrInvalidDocument) || !strings.Contains(err.Error(), want) {
+		t.Errorf("MatchYAML() error = %v, want an ErrInvalidDocument containing %q", err, want)
+	}
+}
+
+func TestMatchYAMLAliasExpansion(t *testing.T) {
+	// the "billion laughs" document: each level expands to 9 copies of
+	// the previous one
+	doc := `
+a: &a ["lol", "lol", "lol", "lol", "lol", "lol", "lol", "lol", "lol"]
+b: &b [*a, *a, *a, *a, *a, *a, *a, *a, *a]
+c: &c [*b, *b, *b, *b, *b, *b, *b, *b, *b]
+d: &d [*c, *c, *c, *c, *c, *c, *c, *c, *c]
+e: &e [*d, *d, *d, *d, *d, *d, *d, *d, *d]
+f: &f [*e, *e, *e, *e, *e, *e, *e, *e, *e]
+g: &g [*f, *f, *f, *f, *f, *f, *f, *f, *f]
+h: &h [*g, *g, *g, *g, *g, *g, *g, *g, *g]
+i: &i [*h, *h, *h, *h, *h, *h, *h, *h, *h]
+`
+	p := matcher.MustCompile(`"#ignore"`)
+	if _, err := p.MatchYAML([]byte(doc)); !errors.Is(err, matcher.ErrInvalidDocument) {
+		t.Errorf("MatchYAML() error = %v, want an ErrInvalidDocument", err)
+	}
+	if _, err := p.MatchYAML([]byte("a: &a [1, 2]\nb: *a\nc: {<<: {x: *a}}\n")); err != nil {
+		t.Errorf("MatchYAML() error = %v", err)
+	}
+}